@@ -0,0 +1,284 @@
+package chaincode
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+)
+
+//defaultBatchConcurrency is the number of invocations of a batch executed concurrently unless set in the config
+const defaultBatchConcurrency = 10
+
+//BatchItem is an invocation of a chaincode function in a batch
+type BatchItem struct {
+	Fcn  string
+	Args [][]byte
+}
+
+//BatchResult is the outcome of an invocation of a batch
+type BatchResult struct {
+	//Index is the position of the item in the batch
+	Index  int
+	Item   BatchItem
+	Result *Result
+	Err    error
+	//Attempts is the number of times the item was executed
+	Attempts int
+}
+
+//BatchProgress is reported each time an invocation of a batch is completed
+type BatchProgress struct {
+	//Received is the number of items received so far
+	Received  int
+	Completed int
+	Succeeded int
+	Failed    int
+	//Last is the result of the invocation just completed
+	Last BatchResult
+}
+
+//BatchRetry defines how failed invocations of a batch are executed again
+type BatchRetry struct {
+	//Attempts is the maximum number of attempts, including the first one. Failed invocations are not retried if 0 or 1.
+	Attempts int
+	//Backoff is the wait before the second attempt, doubled on each following attempt
+	Backoff time.Duration
+	//MaxBackoff caps the wait between attempts, not capped if 0
+	MaxBackoff time.Duration
+	//Retryable reports whether a failed invocation is retried. It defaults to IsRetryable, which only retries failures known not to
+	//have committed the transaction: each attempt is a new transaction, so retrying broader failures, e.g. commit event timeouts,
+	//may execute an item twice and is the caller's choice.
+	Retryable func(err error) bool
+}
+
+//BatchConfig defines how the invocations of a batch are executed
+type BatchConfig struct {
+	//Concurrency is the maximum number of invocations executed concurrently, defaults to 10
+	Concurrency int
+	//Rate is the maximum number of invocations started per second, not limited if 0
+	Rate  float64
+	Retry BatchRetry
+	//OnProgress is called each time an invocation is completed. The calls are serialized.
+	OnProgress func(progress BatchProgress)
+}
+
+//BatchSubmitter executes streams of invocations of the contract chaincode as transactions
+type BatchSubmitter struct {
+	contract *Contract
+	config   BatchConfig
+	limiter  *rateLimiter
+	//submit executes an invocation, the contract submit unless replaced by the tests
+	submit func(ctx context.Context, fn string, args [][]byte) (*Result, error)
+}
+
+//NewBatchSubmitter returns a batch submitter of transactions on the contract
+func NewBatchSubmitter(contract *Contract, config BatchConfig) (*BatchSubmitter, error) {
+	if contract == nil {
+		return nil, errors.New("contract is not set")
+	}
+	if len(contract.options.Nonce) > 0 {
+		return nil, errors.New("a nonce cannot be set on the contract of a batch, every item would have the same transaction ID")
+	}
+	if config.Concurrency < 0 {
+		return nil, errors.Errorf("invalid batch concurrency %d", config.Concurrency)
+	}
+	if config.Rate < 0 {
+		return nil, errors.Errorf("invalid batch rate %v", config.Rate)
+	}
+	if config.Retry.Attempts < 0 || config.Retry.Backoff < 0 || config.Retry.MaxBackoff < 0 {
+		return nil, errors.New("invalid batch retry")
+	}
+	if config.Concurrency == 0 {
+		config.Concurrency = defaultBatchConcurrency
+	}
+	if config.Retry.Retryable == nil {
+		config.Retry.Retryable = IsRetryable
+	}
+	b := new(BatchSubmitter)
+	b.contract = contract
+	b.config = config
+	b.limiter = newRateLimiter(config.Rate)
+	b.submit = contract.submit
+	return b, nil
+}
+
+//IsRetryable reports whether the invocation failed without committing its transaction and may succeed if executed again:
+//the transaction was invalidated by an MVCC or phantom read conflict, or the endorsers could not be reached before it was sent to the orderer.
+//Chaincode rejections are deterministic and timeouts leave the transaction outcome unknown, so neither is retryable.
+func IsRetryable(err error) bool {
+	s, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+	switch s.Group {
+	case status.EventServerStatus:
+		return s.Code == int32(pb.TxValidationCode_MVCC_READ_CONFLICT) || s.Code == int32(pb.TxValidationCode_PHANTOM_READ_CONFLICT)
+	case status.EndorserClientStatus:
+		return s.Code == status.ConnectionFailed.ToInt32()
+	case status.GRPCTransportStatus:
+		//Transport errors are only returned by the endorsers, the orderer errors have their own status groups
+		return s.Code == int32(codes.Unavailable)
+	}
+	return false
+}
+
+//SubmitAll executes the invocations and returns their results in the order of the items
+func (b *BatchSubmitter) SubmitAll(ctx context.Context, items []BatchItem) []BatchResult {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	stream := make(chan BatchItem)
+	go func() {
+		defer close(stream)
+		for _, item := range items {
+			select {
+			case stream <- item:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	results := b.Submit(ctx, stream)
+	//The items not received before the cancellation are reported as cancelled
+	for i := len(results); i < len(items); i++ {
+		results = append(results, BatchResult{Index: i, Item: items[i], Err: ctx.Err()})
+	}
+	return results
+}
+
+//Submit executes the invocations received on the stream until it is closed, and returns their results in the order they were received.
+//Once the context is done, no more items are received, the items waiting to be executed fail with the context error and the running ones are cancelled.
+func (b *BatchSubmitter) Submit(ctx context.Context, items <-chan BatchItem) []BatchResult {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var results []*BatchResult
+	var progressMutex sync.Mutex
+	var progress BatchProgress
+	complete := func(result *BatchResult) {
+		progressMutex.Lock()
+		defer progressMutex.Unlock()
+		progress.Completed++
+		if result.Err == nil {
+			progress.Succeeded++
+		} else {
+			progress.Failed++
+		}
+		if b.config.OnProgress != nil {
+			current := progress
+			current.Last = *result
+			b.config.OnProgress(current)
+		}
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, b.config.Concurrency)
+receive:
+	for index := 0; ; index++ {
+		var item BatchItem
+		var ok bool
+		select {
+		case <-ctx.Done():
+			break receive
+		case item, ok = <-items:
+			if !ok {
+				break receive
+			}
+		}
+		result := &BatchResult{Index: index, Item: item}
+		results = append(results, result)
+		progressMutex.Lock()
+		progress.Received++
+		progressMutex.Unlock()
+
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			result.Err = ctx.Err()
+			complete(result)
+			break receive
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			b.execute(ctx, result)
+			complete(result)
+		}()
+	}
+	wg.Wait()
+
+	ordered := make([]BatchResult, len(results))
+	for i, result := range results {
+		ordered[i] = *result
+	}
+	return ordered
+}
+
+//execute submits the item, retrying according to the retry policy
+func (b *BatchSubmitter) execute(ctx context.Context, result *BatchResult) {
+	for attempt := 1; ; attempt++ {
+		if err := b.limiter.wait(ctx); err != nil {
+			result.Err = err
+			return
+		}
+		result.Attempts = attempt
+		r, err := b.submit(ctx, result.Item.Fcn, result.Item.Args)
+		if err == nil {
+			result.Result = r
+			result.Err = nil
+			return
+		}
+		result.Err = err
+		if attempt >= b.config.Retry.Attempts || !b.config.Retry.Retryable(err) || ctx.Err() != nil {
+			return
+		}
+		select {
+		case <-time.After(exponentialBackoff(b.config.Retry.Backoff, b.config.Retry.MaxBackoff, attempt+1)):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+//rateLimiter spaces the starts of the invocations evenly to respect the rate
+type rateLimiter struct {
+	mutex    sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newRateLimiter(rate float64) *rateLimiter {
+	limiter := new(rateLimiter)
+	if rate > 0 {
+		limiter.interval = time.Duration(float64(time.Second) / rate)
+	}
+	return limiter
+}
+
+//wait blocks until the next invocation may start or the context is done
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l.interval == 0 {
+		return ctx.Err()
+	}
+	l.mutex.Lock()
+	now := time.Now()
+	start := l.next
+	if start.Before(now) {
+		start = now
+	}
+	l.next = start.Add(l.interval)
+	l.mutex.Unlock()
+
+	select {
+	case <-time.After(start.Sub(now)):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
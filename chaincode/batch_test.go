@@ -0,0 +1,197 @@
+package chaincode
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+)
+
+//newTestBatchSubmitter returns a batch submitter executing the invocations with the submit function instead of the contract
+func newTestBatchSubmitter(t *testing.T, config BatchConfig, submit func(ctx context.Context, fn string, args [][]byte) (*Result, error)) *BatchSubmitter {
+	b, err := NewBatchSubmitter(&Contract{}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.submit = submit
+	return b
+}
+
+func batchItems(n int) []BatchItem {
+	items := make([]BatchItem, n)
+	for i := range items {
+		items[i] = BatchItem{Fcn: "put", Args: [][]byte{[]byte(strconv.Itoa(i))}}
+	}
+	return items
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "MVCC read conflict", err: status.New(status.EventServerStatus, int32(pb.TxValidationCode_MVCC_READ_CONFLICT), "invalidated", nil), want: true},
+		{name: "phantom read conflict", err: status.New(status.EventServerStatus, int32(pb.TxValidationCode_PHANTOM_READ_CONFLICT), "invalidated", nil), want: true},
+		{name: "wrapped MVCC read conflict", err: errors.WithMessage(status.New(status.EventServerStatus, int32(pb.TxValidationCode_MVCC_READ_CONFLICT), "invalidated", nil), "execute failed"), want: true},
+		{name: "endorsement policy failure", err: status.New(status.EventServerStatus, int32(pb.TxValidationCode_ENDORSEMENT_POLICY_FAILURE), "invalidated", nil)},
+		{name: "endorser unreachable", err: status.New(status.EndorserClientStatus, status.ConnectionFailed.ToInt32(), "connection failed", nil), want: true},
+		{name: "endorser timeout", err: status.New(status.EndorserClientStatus, status.Timeout.ToInt32(), "timeout", nil)},
+		{name: "endorser unavailable", err: status.New(status.GRPCTransportStatus, int32(codes.Unavailable), "unavailable", nil), want: true},
+		{name: "endorser deadline exceeded", err: status.New(status.GRPCTransportStatus, int32(codes.DeadlineExceeded), "deadline exceeded", nil)},
+		{name: "chaincode rejection", err: status.New(status.ChaincodeStatus, 500, "asset exists", nil)},
+		{name: "commit timeout", err: status.New(status.ClientStatus, status.Timeout.ToInt32(), "timeout", nil)},
+		{name: "plain error", err: errors.New("failed")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := IsRetryable(test.err); got != test.want {
+				t.Errorf("IsRetryable = %t, want %t", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter := newRateLimiter(100)
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := limiter.wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	//The first start is immediate, the four others are spaced by 10ms
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("5 starts at 100 per second took %s", elapsed)
+	}
+
+	limiter = newRateLimiter(0.1)
+	if err := limiter.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := limiter.wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected the wait to end with the context, got %v", err)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := newRateLimiter(0).wait(cancelled); err != context.Canceled {
+		t.Errorf("expected an unlimited wait to fail once the context is done, got %v", err)
+	}
+}
+
+func TestBatchSubmitterSubmitOrder(t *testing.T) {
+	const concurrency = 3
+	var mutex sync.Mutex
+	running, maxRunning := 0, 0
+	b := newTestBatchSubmitter(t, BatchConfig{Concurrency: concurrency}, func(ctx context.Context, fn string, args [][]byte) (*Result, error) {
+		mutex.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mutex.Unlock()
+		//The first items complete last
+		i, _ := strconv.Atoi(string(args[0]))
+		time.Sleep(time.Duration(10-i) * time.Millisecond)
+		mutex.Lock()
+		running--
+		mutex.Unlock()
+		return &Result{Payload: args[0]}, nil
+	})
+	items := batchItems(10)
+	results := b.SubmitAll(context.Background(), items)
+	if len(results) != len(items) {
+		t.Fatalf("expected %d results, got %d", len(items), len(results))
+	}
+	for i, result := range results {
+		if result.Err != nil {
+			t.Fatalf("item %d failed: %v", i, result.Err)
+		}
+		if result.Index != i || string(result.Result.Payload) != strconv.Itoa(i) {
+			t.Errorf("result %d is the result of item %d with payload %s", i, result.Index, result.Result.Payload)
+		}
+	}
+	if maxRunning > concurrency {
+		t.Errorf("%d invocations ran concurrently, the concurrency is %d", maxRunning, concurrency)
+	}
+}
+
+func TestBatchSubmitterRetry(t *testing.T) {
+	conflict := status.New(status.EventServerStatus, int32(pb.TxValidationCode_MVCC_READ_CONFLICT), "invalidated", nil)
+	tests := []struct {
+		name     string
+		attempts int
+		errs     []error
+		want     int
+		err      bool
+	}{
+		{name: "retryable failure retried", attempts: 3, errs: []error{conflict, nil}, want: 2},
+		{name: "attempts exhausted", attempts: 2, errs: []error{conflict, conflict, nil}, want: 2, err: true},
+		{name: "retry disabled", errs: []error{conflict, nil}, want: 1, err: true},
+		{name: "chaincode rejection not retried", attempts: 3, errs: []error{status.New(status.ChaincodeStatus, 500, "asset exists", nil), nil}, want: 1, err: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			calls := 0
+			b := newTestBatchSubmitter(t, BatchConfig{Retry: BatchRetry{Attempts: test.attempts}}, func(ctx context.Context, fn string, args [][]byte) (*Result, error) {
+				err := test.errs[calls]
+				calls++
+				if err != nil {
+					return nil, err
+				}
+				return &Result{}, nil
+			})
+			results := b.SubmitAll(context.Background(), batchItems(1))
+			if results[0].Attempts != test.want {
+				t.Errorf("expected %d attempts, got %d", test.want, results[0].Attempts)
+			}
+			if (results[0].Err != nil) != test.err {
+				t.Errorf("unexpected error %v", results[0].Err)
+			}
+		})
+	}
+}
+
+func TestBatchSubmitterCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{}, 10)
+	b := newTestBatchSubmitter(t, BatchConfig{Concurrency: 2}, func(ctx context.Context, fn string, args [][]byte) (*Result, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	go func() {
+		<-started
+		<-started
+		cancel()
+	}()
+	done := make(chan []BatchResult)
+	go func() {
+		done <- b.SubmitAll(ctx, batchItems(10))
+	}()
+	select {
+	case results := <-done:
+		if len(results) != 10 {
+			t.Fatalf("expected 10 results, got %d", len(results))
+		}
+		for i, result := range results {
+			if result.Index != i {
+				t.Errorf("result %d is the result of item %d", i, result.Index)
+			}
+			if errors.Cause(result.Err) != context.Canceled {
+				t.Errorf("expected item %d to be cancelled, got %v", i, result.Err)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("batch not cancelled with the context")
+	}
+}
@@ -1,7 +1,21 @@
 package chaincode
 
 //ChaincodeClient defines methods for interacting with the chaincode on the fabric network
+//Invoke is a convenience for InvokeResult returning the result payload only.
 type ChaincodeClient interface {
 	Invoke() ([]byte, error)
+	InvokeResult() (*Result, error)
 	Terminate()
 }
+
+//AsyncChaincodeClient defines the chaincode clients submitting transactions without waiting for their commit
+type AsyncChaincodeClient interface {
+	ChaincodeClient
+	InvokeAsync() (*Commit, error)
+}
+
+//ExecuteChaincodeClient defines the chaincode clients executing transactions, which can also be simulated without being committed
+type ExecuteChaincodeClient interface {
+	AsyncChaincodeClient
+	Simulate() (*Simulation, error)
+}
@@ -0,0 +1,160 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+)
+
+//Codec marshals Go values into chaincode arguments and unmarshals chaincode payloads into Go values
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+//List of the available codecs
+var (
+	//JSONCodec marshals values as JSON
+	JSONCodec Codec = jsonCodec{}
+	//ProtoCodec marshals protobuf messages
+	ProtoCodec Codec = protoCodec{}
+	//StringCodec passes strings and byte slices as is
+	StringCodec Codec = stringCodec{}
+	//ContractAPICodec passes strings and byte slices as is and marshals other values as JSON, as expected by the contract API chaincodes
+	ContractAPICodec Codec = contractAPICodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal JSON")
+	}
+	return data, nil
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return errors.Wrap(err, "failed to unmarshal JSON")
+	}
+	return nil
+}
+
+type protoCodec struct{}
+
+func (protoCodec) Marshal(v interface{}) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, errors.Errorf("%T is not a protobuf message", v)
+	}
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal protobuf message")
+	}
+	return data, nil
+}
+
+func (protoCodec) Unmarshal(data []byte, v interface{}) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return errors.Errorf("%T is not a protobuf message", v)
+	}
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return errors.Wrap(err, "failed to unmarshal protobuf message")
+	}
+	return nil
+}
+
+type stringCodec struct{}
+
+func (stringCodec) Marshal(v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return v, nil
+	case fmt.Stringer:
+		return []byte(v.String()), nil
+	}
+	return nil, errors.Errorf("%T cannot be marshalled as a string", v)
+}
+
+func (stringCodec) Unmarshal(data []byte, v interface{}) error {
+	switch v := v.(type) {
+	case *string:
+		*v = string(data)
+	case *[]byte:
+		*v = append((*v)[:0], data...)
+	default:
+		return errors.Errorf("%T cannot be unmarshalled from a string", v)
+	}
+	return nil
+}
+
+type contractAPICodec struct{}
+
+func (contractAPICodec) Marshal(v interface{}) ([]byte, error) {
+	switch v.(type) {
+	case string, []byte:
+		return StringCodec.Marshal(v)
+	}
+	return JSONCodec.Marshal(v)
+}
+
+func (contractAPICodec) Unmarshal(data []byte, v interface{}) error {
+	switch v.(type) {
+	case *string, *[]byte:
+		return StringCodec.Unmarshal(data, v)
+	}
+	return JSONCodec.Unmarshal(data, v)
+}
+
+//MarshalArgs marshals the values into chaincode arguments with the codec
+func MarshalArgs(codec Codec, values ...interface{}) ([][]byte, error) {
+	args := make([][]byte, 0, len(values))
+	for i, value := range values {
+		arg, err := codec.Marshal(value)
+		if err != nil {
+			return nil, errors.WithMessage(err, fmt.Sprintf("failed to marshal argument %d", i))
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+//UnmarshalPayload unmarshals the chaincode payload into v with the codec. Empty payloads and nil values are ignored.
+func UnmarshalPayload(codec Codec, payload []byte, v interface{}) error {
+	if v == nil || len(payload) == 0 {
+		return nil
+	}
+	if err := codec.Unmarshal(payload, v); err != nil {
+		return errors.WithMessage(err, "failed to unmarshal payload")
+	}
+	return nil
+}
+
+//InvokeInto invokes the chaincode client and unmarshals the result payload into v with the codec
+func InvokeInto(client ChaincodeClient, codec Codec, v interface{}) (*Result, error) {
+	result, err := client.InvokeResult()
+	if err != nil {
+		return nil, err
+	}
+	if err := UnmarshalPayload(codec, result.Payload, v); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+//WithCodec sets the codec of the arguments and payloads of a contract, JSONCodec by default
+func WithCodec(codec Codec) Option {
+	return func(opts *Options) error {
+		if codec == nil {
+			return errors.New("codec is not set")
+		}
+		opts.Codec = codec
+		return nil
+	}
+}
@@ -0,0 +1,135 @@
+package chaincode
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/msp"
+)
+
+type testAsset struct {
+	ID    string `json:"id"`
+	Value int    `json:"value,omitempty"`
+}
+
+//testIdentity is a protobuf message and its encoding
+var (
+	testIdentity      = &msp.SerializedIdentity{Mspid: "Org1MSP", IdBytes: []byte("cert")}
+	testIdentityBytes = "\x0a\x07Org1MSP\x12\x04cert"
+)
+
+func TestCodecMarshal(t *testing.T) {
+	tests := []struct {
+		name  string
+		codec Codec
+		value interface{}
+		want  string
+		err   bool
+	}{
+		{name: "JSON object", codec: JSONCodec, value: testAsset{ID: "asset1", Value: 3}, want: `{"id":"asset1","value":3}`},
+		{name: "JSON string", codec: JSONCodec, value: "asset1", want: `"asset1"`},
+		{name: "JSON unsupported value", codec: JSONCodec, value: make(chan int), err: true},
+		{name: "proto message", codec: ProtoCodec, value: testIdentity, want: testIdentityBytes},
+		{name: "proto not a message", codec: ProtoCodec, value: "asset1", err: true},
+		{name: "string", codec: StringCodec, value: "asset1", want: "asset1"},
+		{name: "string bytes", codec: StringCodec, value: []byte("asset1"), want: "asset1"},
+		{name: "string stringer", codec: StringCodec, value: time.Second, want: "1s"},
+		{name: "string number", codec: StringCodec, value: 3, err: true},
+		{name: "contract API string", codec: ContractAPICodec, value: "asset1", want: "asset1"},
+		{name: "contract API bytes", codec: ContractAPICodec, value: []byte("asset1"), want: "asset1"},
+		{name: "contract API number", codec: ContractAPICodec, value: 3, want: "3"},
+		{name: "contract API object", codec: ContractAPICodec, value: testAsset{ID: "asset1"}, want: `{"id":"asset1"}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := test.codec.Marshal(test.value)
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %q", data)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != test.want {
+				t.Errorf("expected %q, got %q", test.want, data)
+			}
+		})
+	}
+}
+
+func TestCodecUnmarshal(t *testing.T) {
+	tests := []struct {
+		name  string
+		codec Codec
+		data  string
+		v     interface{}
+		want  interface{}
+		err   bool
+	}{
+		{name: "JSON object", codec: JSONCodec, data: `{"id":"asset1","value":3}`, v: new(testAsset), want: testAsset{ID: "asset1", Value: 3}},
+		{name: "JSON invalid", codec: JSONCodec, data: `{"id":`, v: new(testAsset), err: true},
+		{name: "proto message", codec: ProtoCodec, data: testIdentityBytes, v: new(msp.SerializedIdentity), want: testIdentity},
+		{name: "proto not a message", codec: ProtoCodec, data: testIdentityBytes, v: new(string), err: true},
+		{name: "string", codec: StringCodec, data: "asset1", v: new(string), want: "asset1"},
+		{name: "string bytes", codec: StringCodec, data: "asset1", v: new([]byte), want: []byte("asset1")},
+		{name: "string number", codec: StringCodec, data: "3", v: new(int), err: true},
+		{name: "contract API string", codec: ContractAPICodec, data: "asset1", v: new(string), want: "asset1"},
+		{name: "contract API number", codec: ContractAPICodec, data: "3", v: new(int), want: 3},
+		{name: "contract API object", codec: ContractAPICodec, data: `{"id":"asset1"}`, v: new(testAsset), want: testAsset{ID: "asset1"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.codec.Unmarshal([]byte(test.data), test.v)
+			if test.err {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if msg, ok := test.v.(proto.Message); ok {
+				if !proto.Equal(msg, test.want.(proto.Message)) {
+					t.Errorf("expected %v, got %v", test.want, msg)
+				}
+				return
+			}
+			if got := reflect.ValueOf(test.v).Elem().Interface(); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("expected %#v, got %#v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestMarshalArgs(t *testing.T) {
+	args, err := MarshalArgs(JSONCodec, "asset1", 3, testAsset{ID: "asset1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]byte{[]byte(`"asset1"`), []byte("3"), []byte(`{"id":"asset1"}`)}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("expected %q, got %q", want, args)
+	}
+	if _, err := MarshalArgs(StringCodec, "asset1", 3); err == nil || !strings.Contains(err.Error(), "argument 1") {
+		t.Errorf("expected an error on argument 1, got %v", err)
+	}
+}
+
+func TestUnmarshalPayload(t *testing.T) {
+	var asset testAsset
+	if err := UnmarshalPayload(JSONCodec, nil, &asset); err != nil {
+		t.Errorf("expected an empty payload to be ignored, got %v", err)
+	}
+	if err := UnmarshalPayload(JSONCodec, []byte(`{"id":"asset1"}`), nil); err != nil {
+		t.Errorf("expected a nil value to be ignored, got %v", err)
+	}
+	if err := UnmarshalPayload(JSONCodec, []byte(`{"id":"asset1"}`), &asset); err != nil || asset.ID != "asset1" {
+		t.Errorf("expected asset1, got %+v and %v", asset, err)
+	}
+}
@@ -0,0 +1,200 @@
+package chaincode
+
+import (
+	"context"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"github.com/pkg/errors"
+)
+
+//defaultCommitTimeout is the time an asynchronous transaction waits for its commit event unless a timeout is set in the options
+const defaultCommitTimeout = 3 * time.Minute
+
+//commitStatus records the commit event of a transaction
+type commitStatus struct {
+	blockNumber uint64
+	sourceURL   string
+}
+
+//commitHandler sends the endorsed transaction to the orderer and waits for its commit event.
+//Unlike the SDK commit handler, it records the block number of the commit event.
+type commitHandler struct {
+	status *commitStatus
+}
+
+//newEndorseHandler returns the handler chain endorsing a transaction, checking that the endorsers returned matching responses, before the next handler.
+//The transaction has the header if set, otherwise the SDK generates it.
+func newEndorseHandler(next invoke.Handler, header *transactionHeader) invoke.Handler {
+	failures := new(endorsementFailures)
+	return invoke.NewProposalProcessorHandler(
+		newEndorsementHandler(header, failures,
+			&consistencyHandler{
+				failures: failures,
+				next: invoke.NewEndorsementValidationHandler(
+					invoke.NewSignatureValidationHandler(next),
+				),
+			},
+		),
+	)
+}
+
+//newExecuteHandler returns the handler chain executing a transaction, recording its commit event in the status
+func newExecuteHandler(commit *commitStatus, header *transactionHeader) invoke.Handler {
+	return newEndorseHandler(&commitHandler{status: commit}, header)
+}
+
+func (h *commitHandler) Handle(requestContext *invoke.RequestContext, clientContext *invoke.ClientContext) {
+	txID := string(requestContext.Response.TransactionID)
+	reg, statusNotifier, err := clientContext.EventService.RegisterTxStatusEvent(txID)
+	if err != nil {
+		requestContext.Error = errors.Wrap(err, "error registering for TxStatus event")
+		return
+	}
+	defer clientContext.EventService.Unregister(reg)
+
+	if err := sendTransaction(clientContext.Transactor, requestContext.Response.Proposal, requestContext.Response.Responses); err != nil {
+		requestContext.Error = err
+		return
+	}
+
+	select {
+	case txStatus := <-statusNotifier:
+		requestContext.Response.TxValidationCode = txStatus.TxValidationCode
+		h.status.blockNumber = txStatus.BlockNumber
+		h.status.sourceURL = txStatus.SourceURL
+		if txStatus.TxValidationCode != pb.TxValidationCode_VALID {
+			requestContext.Error = status.New(status.EventServerStatus, int32(txStatus.TxValidationCode), "received invalid transaction", nil)
+		}
+	case <-requestContext.Ctx.Done():
+		requestContext.Error = status.New(status.ClientStatus, status.Timeout.ToInt32(), "Execute didn't receive block event", nil)
+	}
+}
+
+//Commit is the pending commit status of an asynchronously submitted transaction.
+//It is completed by the commit event received from the client org event source peers, or when waiting for it timed out.
+type Commit struct {
+	txID   string
+	done   chan struct{}
+	result *Result
+	err    error
+	//sourceURL is the URL of the peer the commit event was received from
+	sourceURL string
+}
+
+func newCommit() *Commit {
+	return &Commit{done: make(chan struct{})}
+}
+
+//TransactionID returns the ID of the submitted transaction
+func (c *Commit) TransactionID() string {
+	return c.txID
+}
+
+//Done returns a channel closed once the commit status is known
+func (c *Commit) Done() <-chan struct{} {
+	return c.done
+}
+
+//Status waits for the commit status of the transaction until the context is done.
+//The result holds the validation code, e.g. VALID or MVCC_READ_CONFLICT; the error is set if the transaction was not committed as valid.
+func (c *Commit) Status(ctx context.Context) (*Result, error) {
+	select {
+	case <-c.done:
+		return c.result, c.err
+	case <-ctx.Done():
+		return nil, errors.Wrapf(ctx.Err(), "commit status of transaction %s is pending", c.txID)
+	}
+}
+
+func (c *Commit) complete(result *Result, err error) {
+	c.result = result
+	c.err = err
+	close(c.done)
+}
+
+//asyncCommitHandler sends the endorsed transaction to the orderer and completes the commit in the background once its commit event is received
+type asyncCommitHandler struct {
+	commit  *Commit
+	timeout time.Duration
+}
+
+//newAsyncExecuteHandler returns the handler chain submitting a transaction without waiting for its commit event
+func newAsyncExecuteHandler(commit *Commit, timeout time.Duration, header *transactionHeader) invoke.Handler {
+	return newEndorseHandler(&asyncCommitHandler{commit: commit, timeout: timeout}, header)
+}
+
+func (h *asyncCommitHandler) Handle(requestContext *invoke.RequestContext, clientContext *invoke.ClientContext) {
+	txID := string(requestContext.Response.TransactionID)
+	reg, statusNotifier, err := clientContext.EventService.RegisterTxStatusEvent(txID)
+	if err != nil {
+		requestContext.Error = errors.Wrap(err, "error registering for TxStatus event")
+		return
+	}
+
+	if err := sendTransaction(clientContext.Transactor, requestContext.Response.Proposal, requestContext.Response.Responses); err != nil {
+		clientContext.EventService.Unregister(reg)
+		requestContext.Error = err
+		return
+	}
+
+	h.commit.txID = txID
+	//The request context is cancelled once the handler returns, the commit event is awaited with its own timeout
+	go h.wait(clientContext.EventService, reg, statusNotifier, requestContext.Response)
+}
+
+func (h *asyncCommitHandler) wait(eventService fab.EventService, reg fab.Registration, statusNotifier <-chan *fab.TxStatusEvent, response channel.Response) {
+	defer eventService.Unregister(reg)
+
+	timeout := h.timeout
+	if timeout <= 0 {
+		timeout = defaultCommitTimeout
+	}
+	select {
+	case txStatus := <-statusNotifier:
+		h.commit.sourceURL = txStatus.SourceURL
+		response.TxValidationCode = txStatus.TxValidationCode
+		result := newResult(response)
+		result.BlockNumber = txStatus.BlockNumber
+		if txStatus.TxValidationCode != pb.TxValidationCode_VALID {
+			h.commit.complete(result, status.New(status.EventServerStatus, int32(txStatus.TxValidationCode), "received invalid transaction", nil))
+			return
+		}
+		h.commit.complete(result, nil)
+	case <-time.After(timeout):
+		h.commit.complete(nil, status.New(status.ClientStatus, status.Timeout.ToInt32(), "Execute didn't receive block event", nil))
+	}
+}
+
+//submitHandler sends the endorsed transaction to the orderer without waiting for its commit
+type submitHandler struct {
+}
+
+//newSubmitHandler returns the handler chain submitting a transaction without registering for its commit event
+func newSubmitHandler(header *transactionHeader) invoke.Handler {
+	return newEndorseHandler(&submitHandler{}, header)
+}
+
+func (h *submitHandler) Handle(requestContext *invoke.RequestContext, clientContext *invoke.ClientContext) {
+	if err := sendTransaction(clientContext.Transactor, requestContext.Response.Proposal, requestContext.Response.Responses); err != nil {
+		requestContext.Error = err
+		return
+	}
+	requestContext.Response.TxValidationCode = pb.TxValidationCode_NOT_VALIDATED
+}
+
+//sendTransaction creates the transaction from the endorsed proposal and sends it to the orderer
+func sendTransaction(transactor fab.Transactor, proposal *fab.TransactionProposal, responses []*fab.TransactionProposalResponse) error {
+	tx, err := transactor.CreateTransaction(fab.TransactionRequest{Proposal: proposal, ProposalResponses: responses})
+	if err != nil {
+		return errors.WithMessage(err, "CreateTransaction failed")
+	}
+	if _, err := transactor.SendTransaction(tx); err != nil {
+		return errors.WithMessage(err, "SendTransaction failed")
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package chaincode
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/ledger"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/pkg/errors"
+)
+
+//commitPollInterval is the interval between the queries of the peers a transaction was not yet committed on
+const commitPollInterval = 500 * time.Millisecond
+
+//CommitStrategy defines on which peers an executed transaction must be committed before execute returns
+type CommitStrategy int
+
+//List of commit strategies
+const (
+	//CommitNoWait returns once the transaction is sent to the orderer
+	CommitNoWait CommitStrategy = iota
+	//CommitAnyPeerInOrg waits for the transaction to be committed on any client org peer
+	CommitAnyPeerInOrg
+	//CommitAllPeersInOrg waits for the transaction to be committed on all client org peers
+	CommitAllPeersInOrg
+	//CommitAnyPeerInNetwork waits for the transaction to be committed on any peer of the network
+	CommitAnyPeerInNetwork
+	//CommitQuorumPerOrg waits for the transaction to be committed on a quorum of peers of each org
+	CommitQuorumPerOrg
+)
+
+func (s CommitStrategy) String() string {
+	switch s {
+	case CommitNoWait:
+		return "NoWait"
+	case CommitAnyPeerInOrg:
+		return "AnyPeerInOrg"
+	case CommitAllPeersInOrg:
+		return "AllPeersInOrg"
+	case CommitAnyPeerInNetwork:
+		return "AnyPeerInNetwork"
+	case CommitQuorumPerOrg:
+		return "QuorumPerOrg"
+	}
+	return fmt.Sprintf("CommitStrategy(%d)", int(s))
+}
+
+//CommitWait defines when an executed transaction is considered committed
+type CommitWait struct {
+	Strategy CommitStrategy
+	//Quorum is the number of peers of each org the transaction must be committed on, only used by CommitQuorumPerOrg.
+	//Orgs with fewer peers must have the transaction committed on all of their peers.
+	Quorum int
+	//Timeout is the time to wait for the commit event, defaults to 3 minutes
+	Timeout time.Duration
+	//PeerTimeout is the time to wait, once the commit event is received, for the transaction to be committed on the other peers
+	//of the strategy. It defaults to the commit timeout.
+	PeerTimeout time.Duration
+}
+
+//WithCommitWait sets the peers an executed transaction must be committed on before execute returns.
+//Without it, execute returns once the commit event is received from the client org event source peer.
+//The other peers of the strategy are queried concurrently for the transaction every 500ms, one ledger query per pending peer,
+//so strategies spanning many peers add query load on the network while they wait.
+func WithCommitWait(wait CommitWait) Option {
+	return func(opts *Options) error {
+		if wait.Strategy < CommitNoWait || wait.Strategy > CommitQuorumPerOrg {
+			return errors.Errorf("invalid commit strategy %s", wait.Strategy)
+		}
+		if wait.Strategy == CommitQuorumPerOrg && wait.Quorum <= 0 {
+			return errors.Errorf("invalid commit quorum %d", wait.Quorum)
+		}
+		if wait.Timeout < 0 {
+			return errors.Errorf("invalid commit timeout %s", wait.Timeout)
+		}
+		if wait.PeerTimeout < 0 {
+			return errors.Errorf("invalid peer commit timeout %s", wait.PeerTimeout)
+		}
+		opts.CommitWait = &wait
+		return nil
+	}
+}
+
+func (w CommitWait) timeout() time.Duration {
+	if w.Timeout > 0 {
+		return w.Timeout
+	}
+	return defaultCommitTimeout
+}
+
+func (w CommitWait) peerTimeout() time.Duration {
+	if w.PeerTimeout > 0 {
+		return w.PeerTimeout
+	}
+	return w.timeout()
+}
+
+//peerGroup is a group of peers a transaction must be committed on a number of
+type peerGroup struct {
+	name     string
+	peers    []fab.Peer
+	required int
+}
+
+//peerGroups returns the groups of peers the transaction must be committed on for the strategy
+func (w CommitWait) peerGroups(clientOrgID string, clientOrgPeers []fab.Peer, peersByOrgID map[string][]fab.Peer) []peerGroup {
+	switch w.Strategy {
+	case CommitAnyPeerInOrg:
+		return []peerGroup{{name: clientOrgID, peers: clientOrgPeers, required: 1}}
+	case CommitAllPeersInOrg:
+		return []peerGroup{{name: clientOrgID, peers: clientOrgPeers, required: len(clientOrgPeers)}}
+	case CommitAnyPeerInNetwork:
+		var peers []fab.Peer
+		for _, orgPeers := range peersByOrgID {
+			peers = append(peers, orgPeers...)
+		}
+		return []peerGroup{{name: "network", peers: peers, required: 1}}
+	case CommitQuorumPerOrg:
+		var groups []peerGroup
+		for orgID, orgPeers := range peersByOrgID {
+			required := w.Quorum
+			if required > len(orgPeers) {
+				required = len(orgPeers)
+			}
+			groups = append(groups, peerGroup{name: orgID, peers: orgPeers, required: required})
+		}
+		return groups
+	}
+	return nil
+}
+
+//pending returns the groups whose required number of peers did not commit the transaction yet
+func pending(groups []peerGroup, committed map[string]bool) []peerGroup {
+	var groupsPending []peerGroup
+	for _, group := range groups {
+		count := 0
+		for _, peer := range group.peers {
+			if committed[peerAddress(peer.URL())] {
+				count++
+			}
+		}
+		if count < group.required {
+			groupsPending = append(groupsPending, group)
+		}
+	}
+	return groupsPending
+}
+
+//waitForPeers polls the peers of the pending groups, each in its own goroutine, until the transaction is committed on the
+//required number of peers of each group or the context is done
+func waitForPeers(ctx context.Context, ledgerClient *ledger.Client, txID string, groups []peerGroup, committed map[string]bool) error {
+	groupsPending := pending(groups, committed)
+	if len(groupsPending) == 0 {
+		return nil
+	}
+	pollCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	commits := make(chan string)
+	polled := make(map[string]bool)
+	for _, group := range groupsPending {
+		for _, peer := range group.peers {
+			address := peerAddress(peer.URL())
+			if committed[address] || polled[address] {
+				continue
+			}
+			polled[address] = true
+			go pollPeer(pollCtx, ledgerClient, txID, peer, commits)
+		}
+	}
+	for {
+		select {
+		case address := <-commits:
+			committed[address] = true
+			if len(pending(groups, committed)) == 0 {
+				return nil
+			}
+		case <-ctx.Done():
+			var names []string
+			for _, group := range pending(groups, committed) {
+				names = append(names, group.name)
+			}
+			return status.New(status.ClientStatus, status.Timeout.ToInt32(), fmt.Sprintf("transaction %s was not committed on the required peers of %s", txID, strings.Join(names, ", ")), nil)
+		}
+	}
+}
+
+//pollPeer queries the peer for the transaction until it is committed on the peer, then sends the peer address to commits.
+//The queries are bound to the context so that a stalled peer does not outlive it.
+func pollPeer(ctx context.Context, ledgerClient *ledger.Client, txID string, peer fab.Peer, commits chan<- string) {
+	for {
+		if _, err := ledgerClient.QueryTransaction(fab.TransactionID(txID), ledger.WithTargets(peer), ledger.WithParentContext(ctx)); err == nil {
+			select {
+			case commits <- peerAddress(peer.URL()):
+			case <-ctx.Done():
+			}
+			return
+		}
+		select {
+		case <-time.After(commitPollInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+//peerAddress returns the host and port of the peer URL so that URLs with and without scheme match
+func peerAddress(url string) string {
+	for _, scheme := range []string{"grpcs://", "grpc://"} {
+		url = strings.TrimPrefix(url, scheme)
+	}
+	return url
+}
@@ -0,0 +1,364 @@
+package chaincode
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/pkg/errors"
+)
+
+//EndorsementGroup is a group of endorsers that returned the same proposal response
+type EndorsementGroup struct {
+	Endorsers []string `json:"endorsers"`
+	Status    int32    `json:"status"`
+	Message   string   `json:"message,omitempty"`
+	Payload   Bytes    `json:"payload,omitempty"`
+	responses []*fab.TransactionProposalResponse
+}
+
+//EndorsementDifference describes how the response of an endorser differs from the response of the largest group
+type EndorsementDifference struct {
+	Endorser string `json:"endorser"`
+	//Field is the part of the response that differs: status, payload, rwset, events or response
+	Field  string `json:"field"`
+	Detail string `json:"detail"`
+}
+
+//EndorsementReport compares the proposal responses of the endorsers of a request
+type EndorsementReport struct {
+	//Groups are the endorsers grouped by matching responses, largest group first
+	Groups      []EndorsementGroup      `json:"groups"`
+	Differences []EndorsementDifference `json:"differences,omitempty"`
+	//Failures are the endorsers that could not be reached or whose chaincode rejected the proposal
+	Failures []EndorsementFailure `json:"failures,omitempty"`
+}
+
+//Consistent reports whether all the endorsers returned the same successful response
+func (r *EndorsementReport) Consistent() bool {
+	return len(r.Groups) <= 1 && len(r.Failures) == 0
+}
+
+func (r *EndorsementReport) String() string {
+	var b strings.Builder
+	for i, group := range r.Groups {
+		fmt.Fprintf(&b, "group %d [%s]: status %d, payload %q\n", i+1, strings.Join(group.Endorsers, ", "), group.Status, group.Payload)
+	}
+	for _, d := range r.Differences {
+		fmt.Fprintf(&b, "%s differs on %s: %s\n", d.Endorser, d.Field, d.Detail)
+	}
+	for _, f := range r.Failures {
+		fmt.Fprintf(&b, "%s failed with status %d: %s\n", f.Endorser, f.Status, f.Message)
+	}
+	return b.String()
+}
+
+//EndorsementMismatchError is returned when the endorsers of a request did not return matching responses
+type EndorsementMismatchError struct {
+	Report *EndorsementReport
+	//Quorum is the number of matching responses required by a consensus read, 0 for transactions
+	Quorum int
+}
+
+func (e *EndorsementMismatchError) Error() string {
+	if e.Quorum > 0 {
+		matching := 0
+		if len(e.Report.Groups) > 0 {
+			matching = len(e.Report.Groups[0].Endorsers)
+		}
+		return fmt.Sprintf("no consensus, %d matching responses out of %d required:\n%s", matching, e.Quorum, e.Report)
+	}
+	return fmt.Sprintf("endorsers returned %d different responses:\n%s", len(e.Report.Groups), e.Report)
+}
+
+//AsEndorsementMismatch returns the endorsement mismatch error that caused the error, if any
+func AsEndorsementMismatch(err error) (*EndorsementMismatchError, bool) {
+	mismatch, ok := errors.Cause(err).(*EndorsementMismatchError)
+	return mismatch, ok
+}
+
+//newEndorsementReport groups the successful proposal responses, describes how each response differs from the largest group
+//and lists the failures of the other endorsers
+func newEndorsementReport(responses []*fab.TransactionProposalResponse, failures []EndorsementFailure) (*EndorsementReport, error) {
+	report := new(EndorsementReport)
+	report.Failures = failures
+	for _, response := range responses {
+		if response == nil || response.ProposalResponse == nil || response.ProposalResponse.Response == nil {
+			return nil, errors.New("proposal response is not set")
+		}
+		found := false
+		for i, group := range report.Groups {
+			if sameResponse(group.responses[0], response) {
+				report.Groups[i].Endorsers = append(group.Endorsers, response.Endorser)
+				report.Groups[i].responses = append(group.responses, response)
+				found = true
+				break
+			}
+		}
+		if !found {
+			report.Groups = append(report.Groups, EndorsementGroup{
+				Endorsers: []string{response.Endorser},
+				Status:    response.ProposalResponse.Response.Status,
+				Message:   response.ProposalResponse.Response.Message,
+				Payload:   response.ProposalResponse.Response.Payload,
+				responses: []*fab.TransactionProposalResponse{response},
+			})
+		}
+	}
+	sort.SliceStable(report.Groups, func(i, j int) bool {
+		return len(report.Groups[i].Endorsers) > len(report.Groups[j].Endorsers)
+	})
+	if len(report.Groups) <= 1 {
+		return report, nil
+	}
+	reference := report.Groups[0].responses[0]
+	for _, group := range report.Groups[1:] {
+		differences, err := compareResponses(reference, group.responses[0])
+		if err != nil {
+			return nil, err
+		}
+		for _, endorser := range group.Endorsers {
+			for _, d := range differences {
+				d.Endorser = endorser
+				report.Differences = append(report.Differences, d)
+			}
+		}
+	}
+	return report, nil
+}
+
+func sameResponse(a *fab.TransactionProposalResponse, b *fab.TransactionProposalResponse) bool {
+	return a.ProposalResponse.Response.Status == b.ProposalResponse.Response.Status &&
+		bytes.Equal(a.ProposalResponse.Payload, b.ProposalResponse.Payload)
+}
+
+//compareResponses describes how the response differs from the reference response
+func compareResponses(reference *fab.TransactionProposalResponse, response *fab.TransactionProposalResponse) ([]EndorsementDifference, error) {
+	var differences []EndorsementDifference
+	refResponse, otherResponse := reference.ProposalResponse.Response, response.ProposalResponse.Response
+	if refResponse.Status != otherResponse.Status {
+		differences = append(differences, EndorsementDifference{Field: "status", Detail: fmt.Sprintf("%d (%s) instead of %d", otherResponse.Status, otherResponse.Message, refResponse.Status)})
+	}
+	if !bytes.Equal(refResponse.Payload, otherResponse.Payload) {
+		differences = append(differences, EndorsementDifference{Field: "payload", Detail: fmt.Sprintf("%q instead of %q", otherResponse.Payload, refResponse.Payload)})
+	}
+	refAction, err := chaincodeAction(reference)
+	if err != nil {
+		return nil, err
+	}
+	action, err := chaincodeAction(response)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(refAction.Results, action.Results) {
+		details, err := compareRWSets(refAction.Results, action.Results)
+		if err != nil {
+			return nil, err
+		}
+		for _, detail := range details {
+			differences = append(differences, EndorsementDifference{Field: "rwset", Detail: detail})
+		}
+	}
+	if !bytes.Equal(refAction.Events, action.Events) {
+		differences = append(differences, EndorsementDifference{Field: "events", Detail: "chaincode events differ"})
+	}
+	if len(differences) == 0 {
+		differences = append(differences, EndorsementDifference{Field: "response", Detail: "proposal response payloads differ"})
+	}
+	return differences, nil
+}
+
+//nsRWSet is the decoded public read/write set of a namespace
+type nsRWSet struct {
+	reads       map[string]string
+	writes      map[string]string
+	collections map[string][]byte
+}
+
+func decodeRWSet(results []byte) (map[string]*nsRWSet, error) {
+	decoded, err := decodeReadWriteSet(results)
+	if err != nil {
+		return nil, err
+	}
+	namespaces := make(map[string]*nsRWSet)
+	for _, ns := range decoded.Namespaces {
+		nsSet := &nsRWSet{reads: make(map[string]string), writes: make(map[string]string), collections: make(map[string][]byte)}
+		for _, read := range ns.Reads {
+			nsSet.reads[read.Key] = versionString(read.Version)
+		}
+		for _, write := range ns.Writes {
+			if write.IsDelete {
+				nsSet.writes[write.Key] = "<deleted>"
+			} else {
+				nsSet.writes[write.Key] = fmt.Sprintf("%q", write.Value)
+			}
+		}
+		for _, coll := range ns.Collections {
+			nsSet.collections[coll.Collection] = collectionDigest(coll)
+		}
+		namespaces[ns.Namespace] = nsSet
+	}
+	return namespaces, nil
+}
+
+//collectionDigest returns the hashed reads and writes of the collection, which identify its content
+func collectionDigest(coll CollectionReadWriteSet) []byte {
+	var digest []byte
+	for _, read := range coll.HashedReads {
+		digest = append(digest, read.KeyHash...)
+		digest = append(digest, versionString(read.Version)...)
+	}
+	for _, write := range coll.HashedWrites {
+		digest = append(digest, write.KeyHash...)
+		digest = append(digest, write.ValueHash...)
+	}
+	return digest
+}
+
+func versionString(version *Version) string {
+	if version == nil {
+		return "<none>"
+	}
+	return fmt.Sprintf("%d:%d", version.BlockNum, version.TxNum)
+}
+
+//compareRWSets describes the reads, writes and private data collections that differ between the read/write sets
+func compareRWSets(reference []byte, results []byte) ([]string, error) {
+	refNamespaces, err := decodeRWSet(reference)
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := decodeRWSet(results)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool)
+	for name := range refNamespaces {
+		names[name] = true
+	}
+	for name := range namespaces {
+		names[name] = true
+	}
+	var details []string
+	for _, name := range sortedKeys(names) {
+		ref, other := refNamespaces[name], namespaces[name]
+		if ref == nil || other == nil {
+			details = append(details, fmt.Sprintf("namespace %s is only in one read/write set", name))
+			continue
+		}
+		details = append(details, compareValues(name, "read of", "version", ref.reads, other.reads)...)
+		details = append(details, compareValues(name, "write of", "value", ref.writes, other.writes)...)
+		for coll, hashed := range ref.collections {
+			if !bytes.Equal(hashed, other.collections[coll]) {
+				details = append(details, fmt.Sprintf("private data collection %s of namespace %s differs", coll, name))
+			}
+		}
+		for coll := range other.collections {
+			if _, found := ref.collections[coll]; !found {
+				details = append(details, fmt.Sprintf("private data collection %s of namespace %s is only in one read/write set", coll, name))
+			}
+		}
+	}
+	if len(details) == 0 {
+		details = append(details, "read/write sets differ")
+	}
+	return details, nil
+}
+
+func compareValues(namespace string, operation string, valueName string, reference map[string]string, values map[string]string) []string {
+	var details []string
+	for _, key := range unionKeys(reference, values) {
+		refValue, refFound := reference[key]
+		value, found := values[key]
+		switch {
+		case !refFound:
+			details = append(details, fmt.Sprintf("unexpected %s %s/%s", operation, namespace, key))
+		case !found:
+			details = append(details, fmt.Sprintf("missing %s %s/%s", operation, namespace, key))
+		case refValue != value:
+			details = append(details, fmt.Sprintf("%s %s/%s has %s %s instead of %s", operation, namespace, key, valueName, value, refValue))
+		}
+	}
+	return details
+}
+
+//unionKeys returns the sorted keys of the maps
+func unionKeys(a map[string]string, b map[string]string) []string {
+	keys := make(map[string]bool)
+	for key := range a {
+		keys[key] = true
+	}
+	for key := range b {
+		keys[key] = true
+	}
+	return sortedKeys(keys)
+}
+
+func sortedKeys(keys map[string]bool) []string {
+	var sorted []string
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+//consistencyHandler checks that the endorsers returned matching responses before the SDK endorsement validation,
+//so that divergent responses, including chaincode rejections by some of the endorsers, are reported in detail
+type consistencyHandler struct {
+	failures *endorsementFailures
+	next     invoke.Handler
+}
+
+func (h *consistencyHandler) Handle(requestContext *invoke.RequestContext, clientContext *invoke.ClientContext) {
+	for _, failure := range h.failures.list {
+		//An unreachable endorser is reported as is so that the request can be retried
+		if !failure.rejected() {
+			requestContext.Error = failure.err
+			return
+		}
+	}
+	report, err := newEndorsementReport(requestContext.Response.Responses, h.failures.list)
+	if err != nil {
+		requestContext.Error = err
+		return
+	}
+	if !report.Consistent() {
+		requestContext.Error = &EndorsementMismatchError{Report: report}
+		return
+	}
+	h.next.Handle(requestContext, clientContext)
+}
+
+//consensusHandler requires a quorum of endorsers to return the same successful response, which becomes the response of the request.
+//The endorsers that failed are listed in the report but do not prevent the quorum from being reached by the others.
+type consensusHandler struct {
+	quorum   int
+	failures endorsementFailures
+	report   *EndorsementReport
+}
+
+func (h *consensusHandler) Handle(requestContext *invoke.RequestContext, clientContext *invoke.ClientContext) {
+	report, err := newEndorsementReport(requestContext.Response.Responses, h.failures.list)
+	if err != nil {
+		requestContext.Error = err
+		return
+	}
+	h.report = report
+	for _, group := range report.Groups {
+		if group.Status < 200 || group.Status >= 400 {
+			continue
+		}
+		if len(group.Endorsers) < h.quorum {
+			break
+		}
+		requestContext.Response.Responses = group.responses
+		requestContext.Response.Payload = group.Payload
+		requestContext.Response.ChaincodeStatus = group.Status
+		return
+	}
+	requestContext.Error = &EndorsementMismatchError{Report: report, Quorum: h.quorum}
+}
@@ -0,0 +1,222 @@
+package chaincode
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/ledger/rwset"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/ledger/rwset/kvrwset"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+//testNamespace is the public and private read/write set of a namespace of a test transaction
+type testNamespace struct {
+	name        string
+	kvRWSet     *kvrwset.KVRWSet
+	collections map[string]*kvrwset.HashedRWSet
+}
+
+func marshalTestRWSet(t *testing.T, namespaces ...testNamespace) []byte {
+	txRWSet := new(rwset.TxReadWriteSet)
+	for _, ns := range namespaces {
+		rw, err := proto.Marshal(ns.kvRWSet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nsRWSet := &rwset.NsReadWriteSet{Namespace: ns.name, Rwset: rw}
+		for name, hashed := range ns.collections {
+			hashedRW, err := proto.Marshal(hashed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			nsRWSet.CollectionHashedRwset = append(nsRWSet.CollectionHashedRwset, &rwset.CollectionHashedReadWriteSet{CollectionName: name, HashedRwset: hashedRW})
+		}
+		txRWSet.NsRwset = append(txRWSet.NsRwset, nsRWSet)
+	}
+	results, err := proto.Marshal(txRWSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return results
+}
+
+//newTestProposalResponse returns the proposal response of the endorser writing the key of the assets namespace
+func newTestProposalResponse(t *testing.T, endorser string, status int32, payload string, key string, value string) *fab.TransactionProposalResponse {
+	results := marshalTestRWSet(t, testNamespace{name: "assets", kvRWSet: &kvrwset.KVRWSet{Writes: []*kvrwset.KVWrite{{Key: key, Value: []byte(value)}}}})
+	response := &pb.Response{Status: status, Payload: []byte(payload)}
+	action, err := proto.Marshal(&pb.ChaincodeAction{Results: results, Response: response})
+	if err != nil {
+		t.Fatal(err)
+	}
+	prp, err := proto.Marshal(&pb.ProposalResponsePayload{Extension: action})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &fab.TransactionProposalResponse{
+		Endorser:         endorser,
+		Status:           200,
+		ChaincodeStatus:  status,
+		ProposalResponse: &pb.ProposalResponse{Response: response, Payload: prp},
+	}
+}
+
+func TestNewEndorsementReport(t *testing.T) {
+	tests := []struct {
+		name        string
+		responses   []*fab.TransactionProposalResponse
+		failures    []EndorsementFailure
+		groups      [][]string
+		differences []EndorsementDifference
+		consistent  bool
+	}{
+		{
+			name: "matching responses",
+			responses: []*fab.TransactionProposalResponse{
+				newTestProposalResponse(t, "peer0", 200, "ok", "asset1", "1"),
+				newTestProposalResponse(t, "peer1", 200, "ok", "asset1", "1"),
+			},
+			groups:     [][]string{{"peer0", "peer1"}},
+			consistent: true,
+		},
+		{
+			name: "divergent write",
+			responses: []*fab.TransactionProposalResponse{
+				newTestProposalResponse(t, "peer0", 200, "ok", "asset1", "2"),
+				newTestProposalResponse(t, "peer1", 200, "ok", "asset1", "1"),
+				newTestProposalResponse(t, "peer2", 200, "ok", "asset1", "1"),
+			},
+			groups:      [][]string{{"peer1", "peer2"}, {"peer0"}},
+			differences: []EndorsementDifference{{Endorser: "peer0", Field: "rwset", Detail: `write of assets/asset1 has value "2" instead of "1"`}},
+		},
+		{
+			name: "divergent status and payload",
+			responses: []*fab.TransactionProposalResponse{
+				newTestProposalResponse(t, "peer0", 200, "ok", "asset1", "1"),
+				newTestProposalResponse(t, "peer1", 400, "ko", "asset1", "1"),
+			},
+			groups: [][]string{{"peer0"}, {"peer1"}},
+			differences: []EndorsementDifference{
+				{Endorser: "peer1", Field: "status", Detail: "400 () instead of 200"},
+				{Endorser: "peer1", Field: "payload", Detail: `"ko" instead of "ok"`},
+			},
+		},
+		{
+			name: "failed endorser",
+			responses: []*fab.TransactionProposalResponse{
+				newTestProposalResponse(t, "peer0", 200, "ok", "asset1", "1"),
+			},
+			failures: []EndorsementFailure{{Endorser: "peer1", Status: 500, Message: "asset1 is locked"}},
+			groups:   [][]string{{"peer0"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			report, err := newEndorsementReport(test.responses, test.failures)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var groups [][]string
+			for _, group := range report.Groups {
+				groups = append(groups, group.Endorsers)
+			}
+			if !reflect.DeepEqual(groups, test.groups) {
+				t.Errorf("expected groups %v, got %v", test.groups, groups)
+			}
+			if !reflect.DeepEqual(report.Differences, test.differences) {
+				t.Errorf("expected differences %v, got %v", test.differences, report.Differences)
+			}
+			if !reflect.DeepEqual(report.Failures, test.failures) {
+				t.Errorf("expected failures %v, got %v", test.failures, report.Failures)
+			}
+			if report.Consistent() != test.consistent {
+				t.Errorf("expected consistent %t, got %t", test.consistent, report.Consistent())
+			}
+		})
+	}
+
+	if _, err := newEndorsementReport([]*fab.TransactionProposalResponse{{Endorser: "peer0"}}, nil); err == nil {
+		t.Error("expected an error for a response without proposal response")
+	}
+}
+
+func TestCompareRWSets(t *testing.T) {
+	v := func(blockNum uint64, txNum uint64) *kvrwset.Version {
+		return &kvrwset.Version{BlockNum: blockNum, TxNum: txNum}
+	}
+	reference := testNamespace{
+		name: "assets",
+		kvRWSet: &kvrwset.KVRWSet{
+			Reads:  []*kvrwset.KVRead{{Key: "asset1", Version: v(1, 0)}, {Key: "asset2", Version: v(1, 1)}},
+			Writes: []*kvrwset.KVWrite{{Key: "asset1", Value: []byte("1")}},
+		},
+	}
+	tests := []struct {
+		name       string
+		namespaces []testNamespace
+		details    []string
+	}{
+		{
+			name: "read version",
+			namespaces: []testNamespace{{name: "assets", kvRWSet: &kvrwset.KVRWSet{
+				Reads:  []*kvrwset.KVRead{{Key: "asset1", Version: v(2, 0)}, {Key: "asset2"}},
+				Writes: []*kvrwset.KVWrite{{Key: "asset1", Value: []byte("1")}},
+			}}},
+			details: []string{"read of assets/asset1 has version 2:0 instead of 1:0", "read of assets/asset2 has version <none> instead of 1:1"},
+		},
+		{
+			name: "missing and unexpected keys",
+			namespaces: []testNamespace{{name: "assets", kvRWSet: &kvrwset.KVRWSet{
+				Reads:  []*kvrwset.KVRead{{Key: "asset1", Version: v(1, 0)}},
+				Writes: []*kvrwset.KVWrite{{Key: "asset1", Value: []byte("1")}, {Key: "asset3", Value: []byte("3")}},
+			}}},
+			details: []string{"missing read of assets/asset2", "unexpected write of assets/asset3"},
+		},
+		{
+			name: "deleted key",
+			namespaces: []testNamespace{{name: "assets", kvRWSet: &kvrwset.KVRWSet{
+				Reads:  reference.kvRWSet.Reads,
+				Writes: []*kvrwset.KVWrite{{Key: "asset1", IsDelete: true}},
+			}}},
+			details: []string{`write of assets/asset1 has value <deleted> instead of "1"`},
+		},
+		{
+			name:       "namespace only in one set",
+			namespaces: []testNamespace{reference, {name: "lscc", kvRWSet: &kvrwset.KVRWSet{Reads: []*kvrwset.KVRead{{Key: "assets"}}}}},
+			details:    []string{"namespace lscc is only in one read/write set"},
+		},
+		{
+			name: "private data collection",
+			namespaces: []testNamespace{{name: "assets", kvRWSet: reference.kvRWSet, collections: map[string]*kvrwset.HashedRWSet{
+				"private": {HashedWrites: []*kvrwset.KVWriteHash{{KeyHash: []byte{1}, ValueHash: []byte{2}}}},
+			}}},
+			details: []string{"private data collection private of namespace assets is only in one read/write set"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			details, err := compareRWSets(marshalTestRWSet(t, reference), marshalTestRWSet(t, test.namespaces...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(details, test.details) {
+				t.Errorf("expected details %q, got %q", test.details, details)
+			}
+		})
+	}
+
+	privateRef := testNamespace{name: "assets", kvRWSet: reference.kvRWSet, collections: map[string]*kvrwset.HashedRWSet{
+		"private": {HashedWrites: []*kvrwset.KVWriteHash{{KeyHash: []byte{1}, ValueHash: []byte{2}}}},
+	}}
+	private := testNamespace{name: "assets", kvRWSet: reference.kvRWSet, collections: map[string]*kvrwset.HashedRWSet{
+		"private": {HashedWrites: []*kvrwset.KVWriteHash{{KeyHash: []byte{1}, ValueHash: []byte{3}}}},
+	}}
+	details, err := compareRWSets(marshalTestRWSet(t, privateRef), marshalTestRWSet(t, private))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"private data collection private of namespace assets differs"}; !reflect.DeepEqual(details, want) {
+		t.Errorf("expected details %q, got %q", want, details)
+	}
+}
@@ -0,0 +1,254 @@
+package chaincode
+
+import (
+	"context"
+	"sync"
+
+	"dendrix.io/fabricsdk/providers"
+	"github.com/pkg/errors"
+)
+
+//ProviderFactory returns a new client provider of the client org the contract is bound to
+type ProviderFactory func() (providers.FabricNetworkClientProvider, error)
+
+//Contract is a long-lived handle on a chaincode of a channel for a client org.
+//It shares the cached channel client across invocations and is safe for concurrent use.
+type Contract struct {
+	newProvider ProviderFactory
+	channelID   string
+	chaincodeID string
+	options     Options
+	mutex       sync.Mutex
+	current     *contractProvider
+	closed      bool
+}
+
+//contractProvider counts the invocations using a provider so that it is only closed once they are completed
+type contractProvider struct {
+	providers.FabricNetworkClientProvider
+	refs    int
+	retired bool
+}
+
+//contractLease is a client provider acquired from the contract, closing it releases the provider
+type contractLease struct {
+	*contractProvider
+	contract *Contract
+}
+
+func (l contractLease) CloseSDK() {
+	l.contract.release(l.contractProvider)
+}
+
+//NewContract returns a Contract on the chaincode of the channel using the client providers returned by the factory
+func NewContract(newProvider ProviderFactory, channelID string, chaincodeID string, opts ...Option) (*Contract, error) {
+	if newProvider == nil {
+		return nil, errors.Errorf("Fabric network client provider factory is not set.")
+	}
+	if channelID == "" || chaincodeID == "" {
+		return nil, errors.Errorf("invalid contract, missing required field(s): ChannelID, ChaincodeID")
+	}
+	options, err := NewOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if len(options.Nonce) > 0 {
+		//Every transaction of the contract would have the same ID and all but the first would be rejected as duplicates
+		return nil, errors.New("a nonce cannot be set on a contract, it only applies to a single transaction")
+	}
+	c := new(Contract)
+	c.newProvider = newProvider
+	c.channelID = channelID
+	c.chaincodeID = chaincodeID
+	c.options = options
+	return c, nil
+}
+
+//ChannelID returns the channel of the contract
+func (c *Contract) ChannelID() string {
+	return c.channelID
+}
+
+//ChaincodeID returns the chaincode of the contract
+func (c *Contract) ChaincodeID() string {
+	return c.chaincodeID
+}
+
+//Submit executes the chaincode function as a transaction and returns its result once committed
+func (c *Contract) Submit(fn string, args ...[]byte) (*Result, error) {
+	return c.submit(c.options.Context, fn, args)
+}
+
+//submit executes the chaincode function as a transaction cancelled once the context is done
+func (c *Contract) submit(ctx context.Context, fn string, args [][]byte) (*Result, error) {
+	provider, err := c.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer c.release(provider)
+	options := c.options
+	options.Context = ctx
+	client := newExecuteClient(provider, c.request(fn, args), options)
+	return client.InvokeResult()
+}
+
+//SubmitAsync submits the chaincode function as a transaction and returns without waiting for its commit
+func (c *Contract) SubmitAsync(fn string, args ...[]byte) (*Commit, error) {
+	provider, err := c.acquire()
+	if err != nil {
+		return nil, err
+	}
+	client := newExecuteClient(provider, c.request(fn, args), c.options)
+	commit, err := client.InvokeAsync()
+	if err != nil {
+		c.release(provider)
+		return nil, err
+	}
+	//The provider is kept until the commit event is received
+	go func() {
+		<-commit.Done()
+		c.release(provider)
+	}()
+	return commit, nil
+}
+
+//Simulate endorses the chaincode function without sending the transaction to the orderer and returns the decoded endorsements
+func (c *Contract) Simulate(fn string, args ...[]byte) (*Simulation, error) {
+	provider, err := c.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer c.release(provider)
+	client := newExecuteClient(provider, c.request(fn, args), c.options)
+	return client.Simulate()
+}
+
+//SubmitValue marshals the values into arguments with the contract codec, submits the chaincode function
+//and unmarshals the payload into result once committed
+func (c *Contract) SubmitValue(fn string, result interface{}, values ...interface{}) (*Result, error) {
+	args, err := MarshalArgs(c.codec(), values...)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.Submit(fn, args...)
+	if err != nil {
+		return nil, err
+	}
+	if err := UnmarshalPayload(c.codec(), r.Payload, result); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+//EvaluateValue marshals the values into arguments with the contract codec, queries the chaincode function
+//and unmarshals the payload into result
+func (c *Contract) EvaluateValue(fn string, result interface{}, values ...interface{}) (*Result, error) {
+	args, err := MarshalArgs(c.codec(), values...)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.Evaluate(fn, args...)
+	if err != nil {
+		return nil, err
+	}
+	if err := UnmarshalPayload(c.codec(), r.Payload, result); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+//Evaluate queries the chaincode function and returns its result
+func (c *Contract) Evaluate(fn string, args ...[]byte) (*Result, error) {
+	provider, err := c.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer c.release(provider)
+	client := newQueryClient(provider, c.request(fn, args), c.options)
+	return client.InvokeResult()
+}
+
+//Iterate returns an iterator over the items of the paginated query of the chaincode function. Close it when no longer needed.
+func (c *Contract) Iterate(ctx context.Context, fn string, pageSize int32, args ...[]byte) (*QueryIterator, error) {
+	query := PageQuery{InvokeRequest: c.request(fn, args), PageSize: pageSize}
+	return c.IteratePages(ctx, query)
+}
+
+//IteratePages returns an iterator over the items of the paginated query, on the channel and chaincode of the contract. Close it when no longer needed.
+func (c *Contract) IteratePages(ctx context.Context, query PageQuery) (*QueryIterator, error) {
+	query.ChannelID = c.channelID
+	query.ChaincodeID = c.chaincodeID
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	provider, err := c.acquire()
+	if err != nil {
+		return nil, err
+	}
+	return newQueryIterator(ctx, contractLease{contractProvider: provider, contract: c}, query, c.options), nil
+}
+
+//Close releases the client provider of the contract once the running invocations are completed
+func (c *Contract) Close() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.closed = true
+	if c.current != nil {
+		c.retire(c.current)
+		c.current = nil
+	}
+}
+
+func (c *Contract) codec() Codec {
+	if c.options.Codec != nil {
+		return c.options.Codec
+	}
+	return JSONCodec
+}
+
+func (c *Contract) request(fn string, args [][]byte) InvokeRequest {
+	return InvokeRequest{
+		ChannelID:   c.channelID,
+		ChaincodeID: c.chaincodeID,
+		Fcn:         fn,
+		Args:        args,
+	}
+}
+
+//acquire returns the current client provider, replacing it if the config was reloaded meanwhile
+func (c *Contract) acquire() (*contractProvider, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.closed {
+		return nil, errors.Errorf("contract %s on channel %s is closed", c.chaincodeID, c.channelID)
+	}
+	if c.current != nil && c.current.Stale() {
+		c.retire(c.current)
+		c.current = nil
+	}
+	if c.current == nil {
+		provider, err := c.newProvider()
+		if err != nil {
+			return nil, err
+		}
+		c.current = &contractProvider{FabricNetworkClientProvider: provider}
+	}
+	c.current.refs++
+	return c.current, nil
+}
+
+func (c *Contract) release(provider *contractProvider) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	provider.refs--
+	if provider.refs == 0 && provider.retired {
+		provider.CloseSDK()
+	}
+}
+
+func (c *Contract) retire(provider *contractProvider) {
+	provider.retired = true
+	if provider.refs == 0 {
+		provider.CloseSDK()
+	}
+}
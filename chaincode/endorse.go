@@ -0,0 +1,127 @@
+package chaincode
+
+import (
+	"sync"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/txn"
+	"github.com/pkg/errors"
+)
+
+//EndorsementFailure is an endorser that did not return a successful proposal response
+type EndorsementFailure struct {
+	Endorser string `json:"endorser"`
+	//Status is the status returned by the chaincode, 0 if the endorser could not be reached
+	Status  int32  `json:"status,omitempty"`
+	Message string `json:"message"`
+	err     error
+}
+
+//newEndorsementFailure returns the failure of the endorser from the error of its proposal
+func newEndorsementFailure(endorser string, err error) EndorsementFailure {
+	failure := EndorsementFailure{Endorser: endorser, Message: err.Error(), err: err}
+	//Peers report chaincode errors in the chaincode status group, older peers in the endorser server status group
+	if s, ok := status.FromError(err); ok && (s.Group == status.ChaincodeStatus || s.Group == status.EndorserServerStatus) {
+		failure.Status = s.Code
+		failure.Message = s.Message
+	}
+	return failure
+}
+
+//rejected reports whether the endorser was reached and the chaincode rejected the proposal
+func (f EndorsementFailure) rejected() bool {
+	return f.Status != 0
+}
+
+//endorsementFailures collects the failures of the endorsers of a request, shared by the handlers of its chain
+type endorsementFailures struct {
+	list []EndorsementFailure
+}
+
+//endorsementHandler sends the proposal to each target separately so that the failure of an endorser does not discard
+//the responses of the others, unlike the SDK endorsement handler. The failures are collected for the next handlers.
+//If a header is set, the proposal has its transaction ID, otherwise the SDK generates it.
+type endorsementHandler struct {
+	header   *transactionHeader
+	failures *endorsementFailures
+	next     invoke.Handler
+}
+
+//newEndorsementHandler returns the handler endorsing the proposal, collecting the failures of the endorsers
+func newEndorsementHandler(header *transactionHeader, failures *endorsementFailures, next invoke.Handler) invoke.Handler {
+	return &endorsementHandler{header: header, failures: failures, next: next}
+}
+
+func (h *endorsementHandler) Handle(requestContext *invoke.RequestContext, clientContext *invoke.ClientContext) {
+	targets := requestContext.Opts.Targets
+	if len(targets) == 0 {
+		requestContext.Error = status.New(status.ClientStatus, status.NoPeersFound.ToInt32(), "targets were not provided", nil)
+		return
+	}
+	var txh fab.TransactionHeader = h.header
+	if h.header == nil {
+		header, err := clientContext.Transactor.CreateTransactionHeader()
+		if err != nil {
+			requestContext.Error = errors.WithMessage(err, "creating transaction header failed")
+			return
+		}
+		txh = header
+	}
+	proposal, err := txn.CreateChaincodeInvokeProposal(txh, fab.ChaincodeInvokeRequest{
+		ChaincodeID:  requestContext.Request.ChaincodeID,
+		Fcn:          requestContext.Request.Fcn,
+		Args:         requestContext.Request.Args,
+		TransientMap: requestContext.Request.TransientMap,
+	})
+	if err != nil {
+		requestContext.Error = errors.WithMessage(err, "creating transaction proposal failed")
+		return
+	}
+	requestContext.Response.Proposal = proposal
+	requestContext.Response.TransactionID = proposal.TxnID
+
+	responses := make([]*fab.TransactionProposalResponse, len(targets))
+	errs := make([]error, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target fab.Peer) {
+			defer wg.Done()
+			targetResponses, err := clientContext.Transactor.SendTransactionProposal(proposal, []fab.ProposalProcessor{target})
+			if err == nil && len(targetResponses) == 0 {
+				err = errors.New("no proposal response")
+			}
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			responses[i] = targetResponses[0]
+		}(i, target)
+	}
+	wg.Wait()
+
+	//The failures of a previous attempt of the request are replaced, the handler chain being run again on retries
+	var endorsed []*fab.TransactionProposalResponse
+	var failures []EndorsementFailure
+	for i, target := range targets {
+		if errs[i] != nil {
+			failures = append(failures, newEndorsementFailure(target.URL(), errs[i]))
+			continue
+		}
+		endorsed = append(endorsed, responses[i])
+	}
+	h.failures.list = failures
+	if len(endorsed) == 0 {
+		requestContext.Error = failures[0].err
+		return
+	}
+	requestContext.Response.Responses = endorsed
+	requestContext.Response.Payload = endorsed[0].ProposalResponse.GetResponse().Payload
+	requestContext.Response.ChaincodeStatus = endorsed[0].ChaincodeStatus
+
+	if h.next != nil {
+		h.next.Handle(requestContext, clientContext)
+	}
+}
@@ -0,0 +1,125 @@
+package chaincode
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"github.com/pkg/errors"
+)
+
+//stubPeer is a target peer identified by its URL
+type stubPeer struct {
+	fab.Peer
+	url string
+}
+
+func (p *stubPeer) URL() string {
+	return p.url
+}
+
+//stubTransactor endorses the proposals on every peer but the ones with an error
+type stubTransactor struct {
+	fab.Transactor
+	errs map[string]error
+}
+
+func (t *stubTransactor) SendTransactionProposal(proposal *fab.TransactionProposal, targets []fab.ProposalProcessor) ([]*fab.TransactionProposalResponse, error) {
+	url := targets[0].(fab.Peer).URL()
+	if err := t.errs[url]; err != nil {
+		return nil, err
+	}
+	return []*fab.TransactionProposalResponse{{
+		Endorser:         url,
+		Status:           200,
+		ChaincodeStatus:  200,
+		ProposalResponse: &pb.ProposalResponse{Response: &pb.Response{Status: 200, Payload: []byte("ok")}},
+	}}, nil
+}
+
+func TestEndorsementHandlerAttempts(t *testing.T) {
+	unreachable := status.New(status.EndorserClientStatus, status.ConnectionFailed.ToInt32(), "connection failed", nil)
+	rejected := status.New(status.EndorserServerStatus, 500, "asset not found", nil)
+	firstAttempt := errors.New("first attempt failed")
+	lastAttempt := errors.New("last attempt failed")
+
+	header, err := newTransactionHeader("mychannel", nil, []byte("creator"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	peers := []fab.Peer{&stubPeer{url: "peer0"}, &stubPeer{url: "peer1"}}
+	transactor := &stubTransactor{}
+	failures := new(endorsementFailures)
+	handler := newEndorsementHandler(header, failures, nil)
+
+	//The attempts run the same handler, as the SDK does when it retries a request
+	attempts := []struct {
+		name      string
+		errs      map[string]error
+		endorsers []string
+		failed    []string
+		err       error
+	}{
+		{name: "unreachable peer", errs: map[string]error{"peer1": unreachable}, endorsers: []string{"peer0"}, failed: []string{"peer1"}},
+		{name: "all endorsed", endorsers: []string{"peer0", "peer1"}},
+		{name: "rejected by chaincode", errs: map[string]error{"peer0": rejected}, endorsers: []string{"peer1"}, failed: []string{"peer0"}},
+		{name: "all failed", errs: map[string]error{"peer0": firstAttempt, "peer1": firstAttempt}, failed: []string{"peer0", "peer1"}, err: firstAttempt},
+		{name: "all failed again", errs: map[string]error{"peer0": lastAttempt, "peer1": rejected}, failed: []string{"peer0", "peer1"}, err: lastAttempt},
+		{name: "recovered", endorsers: []string{"peer0", "peer1"}},
+	}
+	for _, attempt := range attempts {
+		transactor.errs = attempt.errs
+		requestContext := &invoke.RequestContext{
+			Request: invoke.Request{ChaincodeID: "mycc", Fcn: "get", Args: [][]byte{[]byte("asset1")}},
+			Opts:    invoke.Opts{Targets: peers},
+		}
+		handler.Handle(requestContext, &invoke.ClientContext{Transactor: transactor})
+
+		if requestContext.Error != attempt.err {
+			t.Errorf("%s: error %v, expected %v", attempt.name, requestContext.Error, attempt.err)
+		}
+		var failed []string
+		for _, failure := range failures.list {
+			failed = append(failed, failure.Endorser)
+		}
+		if !reflect.DeepEqual(failed, attempt.failed) {
+			t.Errorf("%s: failed endorsers %v, expected %v", attempt.name, failed, attempt.failed)
+		}
+		if attempt.err != nil {
+			continue
+		}
+		var endorsers []string
+		for _, response := range requestContext.Response.Responses {
+			endorsers = append(endorsers, response.Endorser)
+		}
+		if !reflect.DeepEqual(endorsers, attempt.endorsers) {
+			t.Errorf("%s: endorsers %v, expected %v", attempt.name, endorsers, attempt.endorsers)
+		}
+		if string(requestContext.Response.TransactionID) != string(header.TransactionID()) {
+			t.Errorf("%s: transaction ID %s, expected %s", attempt.name, requestContext.Response.TransactionID, header.TransactionID())
+		}
+	}
+}
+
+func TestEndorsementFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		status   int32
+		message  string
+		rejected bool
+	}{
+		{name: "chaincode error", err: status.New(status.EndorserServerStatus, 500, "asset not found", nil), status: 500, message: "asset not found", rejected: true},
+		{name: "chaincode status", err: status.New(status.ChaincodeStatus, 404, "asset not found", nil), status: 404, message: "asset not found", rejected: true},
+		{name: "connection failed", err: errors.New("connection refused"), message: "connection refused"},
+	}
+	for _, test := range tests {
+		failure := newEndorsementFailure("peer0", test.err)
+		if failure.Status != test.status || failure.Message != test.message || failure.rejected() != test.rejected {
+			t.Errorf("%s: failure %+v, expected status %d, message %q, rejected %t", test.name, failure, test.status, test.message, test.rejected)
+		}
+	}
+}
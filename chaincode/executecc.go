@@ -1,17 +1,21 @@
 package chaincode
 
 import (
+	"context"
+	"fmt"
 	"math/rand"
+	"time"
 
 	"dendrix.io/fabricsdk/providers"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 	"github.com/pkg/errors"
 )
 
 //Invoke invokes chaincode
 /* func (setup *FabricSetup) Invoke(function string, invokeArgs [][]byte) (string, error) {
-	//fmt.Println("@Invoke -> Chaincode:", setup.ChainCodeID, "Function:", function, "Args length:", len(invokeArgs))
 	setup.CreateChannelClient()
 
 	peerIndex := 0
@@ -37,23 +41,241 @@ type executeChaincodeClient struct {
 	chaincodeID string
 	args        [][]byte
 	function    string
+	targets     []fab.Peer
+	timeout     time.Duration
+	ctx         context.Context
+	metadata    *MetadataCache
+	commitWait  *CommitWait
+	mvccRetry   *MVCCRetry
+	nonce       []byte
+	creator     []byte
 }
 
 //NewExecuteClient returns a ChaincodeClient implmentation for executing chaincode business functions
 func NewExecuteClient(provider providers.FabricNetworkClientProvider, channelID string, chaincodeID string, fn string, args [][]byte) ChaincodeClient {
+	req := InvokeRequest{
+		ChannelID:   channelID,
+		ChaincodeID: chaincodeID,
+		Fcn:         fn,
+		Args:        args,
+	}
+	return newExecuteClient(provider, req, Options{})
+}
+
+//NewExecuteClientFromRequest returns a ChaincodeClient implmentation for executing the requested chaincode function.
+//Unless targets are set in the options, the transaction is endorsed by all the client org peers.
+func NewExecuteClientFromRequest(provider providers.FabricNetworkClientProvider, req InvokeRequest, opts ...Option) (ExecuteChaincodeClient, error) {
+	if provider == nil {
+		return nil, errors.Errorf("Fabric network client provider is not set.")
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	options, err := NewOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := options.Validate(ExecuteRequestType); err != nil {
+		return nil, err
+	}
+	return newExecuteClient(provider, req, options), nil
+}
+
+func newExecuteClient(provider providers.FabricNetworkClientProvider, req InvokeRequest, options Options) *executeChaincodeClient {
 	i := new(executeChaincodeClient)
 	i.FabricNetworkClientProvider = provider
-	i.channelID = channelID
-	i.chaincodeID = chaincodeID
-	i.args = args
-	i.function = fn
+	i.channelID = req.ChannelID
+	i.chaincodeID = req.ChaincodeID
+	i.args = req.Args
+	i.function = req.Fcn
+	i.targets = options.Targets
+	i.timeout = options.Timeout
+	i.ctx = options.Context
+	i.metadata = options.MetadataCache
+	i.commitWait = options.CommitWait
+	i.mvccRetry = options.MVCCRetry
+	i.nonce = options.Nonce
+	i.creator = options.Creator
 	return i
 }
 
 func (ic executeChaincodeClient) Invoke() ([]byte, error) {
+	result, err := ic.InvokeResult()
+	if err != nil {
+		return nil, err
+	}
+	return result.Payload, nil
+}
+
+//InvokeResult executes the chaincode function and returns the committed transaction result.
+//If a commit wait is set in the options, it returns once the commit strategy is satisfied.
+//If an MVCC retry is set in the options, transactions invalidated by an MVCC read conflict are endorsed and submitted again.
+func (ic executeChaincodeClient) InvokeResult() (*Result, error) {
+	if ic.mvccRetry != nil {
+		if len(ic.nonce) > 0 {
+			//The resubmitted transaction would have the ID of the invalidated one and be rejected as a duplicate
+			return nil, errors.New("MVCC retry cannot resubmit a transaction with a nonce set in the options")
+		}
+		ctx := ic.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		return retryOnMVCCReadConflict(ctx, *ic.mvccRetry, ic.invokeResult)
+	}
+	result, err := ic.invokeResult()
+	if err != nil {
+		return nil, err
+	}
+	result.Attempts = 1
+	return result, nil
+}
+
+func (ic executeChaincodeClient) invokeResult() (*Result, error) {
+	if ic.commitWait != nil {
+		return ic.invokeWithCommitWait(*ic.commitWait)
+	}
+	header, err := ic.transactionHeader()
+	if err != nil {
+		return nil, err
+	}
+	commit := new(commitStatus)
+	response, err := ic.invokeHandler(newExecuteHandler(commit, header))
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("failed to invoke function %s on chaincode %s", ic.function, ic.chaincodeID))
+	}
+
+	result := newResult(response)
+	result.BlockNumber = commit.blockNumber
+	return result, nil
+}
+
+//invokeWithCommitWait executes the chaincode function and waits for its commit on the peers of the commit strategy
+func (ic executeChaincodeClient) invokeWithCommitWait(wait CommitWait) (*Result, error) {
+	if wait.Strategy == CommitNoWait {
+		header, err := ic.transactionHeader()
+		if err != nil {
+			return nil, err
+		}
+		response, err := ic.invokeHandler(newSubmitHandler(header))
+		if err != nil {
+			return nil, errors.WithMessage(err, fmt.Sprintf("failed to submit function %s on chaincode %s", ic.function, ic.chaincodeID))
+		}
+		return newResult(response), nil
+	}
+
+	commit, err := ic.invokeAsync(wait.timeout())
+	if err != nil {
+		return nil, err
+	}
+	parent := ic.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	eventCtx, cancelEvent := context.WithTimeout(parent, wait.timeout())
+	defer cancelEvent()
+	result, err := commit.Status(eventCtx)
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("failed to invoke function %s on chaincode %s", ic.function, ic.chaincodeID))
+	}
+
+	//The event source peer committed the transaction, the other peers of the strategy are queried within their own timeout
+	ledgerClient, err := ic.LedgerClient(ic.channelID)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(parent, wait.peerTimeout())
+	defer cancel()
+	committed := map[string]bool{peerAddress(commit.sourceURL): true}
+	groups := wait.peerGroups(ic.ClientOrgID(), ic.ClientOrgPeers(), ic.PeersByOrgID())
+	if err := waitForPeers(ctx, ledgerClient, result.TxID, groups, committed); err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("failed to invoke function %s on chaincode %s", ic.function, ic.chaincodeID))
+	}
+	return result, nil
+}
+
+//InvokeAsync submits the chaincode function as a transaction and returns once it is sent to the orderer.
+//The returned commit is completed by the commit event of the transaction.
+func (ic executeChaincodeClient) InvokeAsync() (*Commit, error) {
+	return ic.invokeAsync(ic.timeout)
+}
+
+func (ic executeChaincodeClient) invokeAsync(commitTimeout time.Duration) (*Commit, error) {
+	header, err := ic.transactionHeader()
+	if err != nil {
+		return nil, err
+	}
+	commit := newCommit()
+	if _, err := ic.invokeHandler(newAsyncExecuteHandler(commit, commitTimeout, header)); err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("failed to submit function %s on chaincode %s", ic.function, ic.chaincodeID))
+	}
+	return commit, nil
+}
+
+//Simulate endorses the chaincode function without sending the transaction to the orderer.
+//It returns the decoded responses of the endorsers: payload, read/write set, chaincode event and status.
+//The endorsers that could not be reached or rejected the proposal are listed in the failures of the consistency report.
+func (ic executeChaincodeClient) Simulate() (*Simulation, error) {
+	header, err := ic.transactionHeader()
+	if err != nil {
+		return nil, err
+	}
+	failures := new(endorsementFailures)
+	response, err := ic.invokeHandler(newSimulateHandler(header, failures))
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("failed to simulate function %s on chaincode %s", ic.function, ic.chaincodeID))
+	}
+	simulation := new(Simulation)
+	simulation.TxID = string(response.TransactionID)
+	simulation.Payload = response.Payload
+	simulation.ChaincodeStatus = response.ChaincodeStatus
+	for _, r := range response.Responses {
+		endorsement, err := DecodeEndorsement(r)
+		if err != nil {
+			return nil, errors.WithMessage(err, fmt.Sprintf("failed to decode simulation response of function %s on chaincode %s from peer %s", ic.function, ic.chaincodeID, r.Endorser))
+		}
+		simulation.Endorsements = append(simulation.Endorsements, endorsement)
+	}
+	if simulation.Endorsement, err = newEndorsementReport(response.Responses, failures.list); err != nil {
+		return nil, err
+	}
+	return simulation, nil
+}
+
+func (ic executeChaincodeClient) request() InvokeRequest {
+	return InvokeRequest{ChannelID: ic.channelID, ChaincodeID: ic.chaincodeID, Fcn: ic.function, Args: ic.args}
+}
+
+//transactionHeader returns the header of the transaction if a nonce or creator is set in the options, nil to let the SDK generate it
+func (ic executeChaincodeClient) transactionHeader() (*transactionHeader, error) {
+	if len(ic.nonce) == 0 && len(ic.creator) == 0 {
+		return nil, nil
+	}
+	user := ic.ClientUser()
+	if user == nil {
+		return nil, errors.Errorf("client org %s has no user configured", ic.ClientOrgID())
+	}
+	creator, err := user.Serialize()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to serialize the identity signing the transaction")
+	}
+	if err := checkCreator(ic.creator, creator); err != nil {
+		return nil, err
+	}
+	return newTransactionHeader(ic.channelID, ic.nonce, creator)
+}
+
+//invokeHandler sends the chaincode function request through the handler chain.
+//If metadata validation is set in the options, the request is checked against the chaincode metadata first.
+//Unless targets are set, the request is endorsed by all the client org peers so that divergent endorsements are detected.
+func (ic executeChaincodeClient) invokeHandler(handler invoke.Handler) (channel.Response, error) {
+	if ic.metadata != nil {
+		if err := ic.metadata.validate(ic.FabricNetworkClientProvider, ic.request()); err != nil {
+			return channel.Response{}, err
+		}
+	}
 	chClient, err := ic.ChannelClient(ic.channelID)
 	if err != nil {
-		return []byte("0x00"), err
+		return channel.Response{}, err
 	}
 
 	req := channel.Request{
@@ -61,25 +283,34 @@ func (ic executeChaincodeClient) Invoke() ([]byte, error) {
 		Fcn:         ic.function,
 		Args:        ic.args,
 	}
-	target := ic.peer(ic.ClientOrgPeers())
-	response, err := chClient.Execute(req, channel.WithTargets(target))
-
-	if err != nil {
-		return nil, errors.Errorf("failed to invoke function %s on chaincode %s. Error - %s", req.Fcn, req.ChaincodeID, err.Error())
+	targets := ic.targets
+	if len(targets) == 0 {
+		targets = ic.ClientOrgPeers()
 	}
-
-	return response.Payload, nil
+	if len(targets) == 0 {
+		return channel.Response{}, errNoPeersFound(ic.ClientOrgID())
+	}
+	return chClient.InvokeHandler(handler, req, channelRequestOptions(targets, ic.timeout, fab.Execute, ic.ctx)...)
 }
 
 func (ic executeChaincodeClient) Terminate() {
 	ic.CloseSDK()
 }
 
-func (ic executeChaincodeClient) peer(targets []fab.Peer) fab.Peer {
+//randomPeer returns a random peer among the client org peers to spread the load
+func randomPeer(clientOrgID string, targets []fab.Peer) (fab.Peer, error) {
+	if len(targets) == 0 {
+		return nil, errNoPeersFound(clientOrgID)
+	}
 	peerIndex := 0
 	numberOfpeers := len(targets)
 	if numberOfpeers > 1 {
 		peerIndex = rand.Intn(numberOfpeers)
 	}
-	return targets[peerIndex]
+	return targets[peerIndex], nil
+}
+
+//errNoPeersFound is the error of a request without targets in a client org without peers
+func errNoPeersFound(clientOrgID string) error {
+	return status.New(status.ClientStatus, status.NoPeersFound.ToInt32(), fmt.Sprintf("no peers found for org %s", clientOrgID), nil)
 }
@@ -0,0 +1,232 @@
+package chaincode
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"dendrix.io/fabricsdk/providers"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/msp"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"github.com/pkg/errors"
+)
+
+//Bytes is a decoded value. It is rendered in JSON as an object holding the value as base64 and, if it is valid UTF-8, as text:
+//{"base64": "aGVsbG8=", "text": "hello"}
+type Bytes []byte
+
+//bytesJSON is the JSON form of Bytes
+type bytesJSON struct {
+	Base64 []byte  `json:"base64"`
+	Text   *string `json:"text,omitempty"`
+}
+
+//MarshalJSON renders the value as base64, with its text if it is valid UTF-8
+func (b Bytes) MarshalJSON() ([]byte, error) {
+	value := bytesJSON{Base64: []byte(b)}
+	if value.Base64 == nil {
+		value.Base64 = []byte{}
+	}
+	if utf8.Valid(b) {
+		text := string(b)
+		value.Text = &text
+	}
+	return json.Marshal(value)
+}
+
+//UnmarshalJSON decodes the value from its base64 form
+func (b *Bytes) UnmarshalJSON(data []byte) error {
+	var value bytesJSON
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	*b = value.Base64
+	return nil
+}
+
+//Hash is a decoded hash, rendered in JSON as hex
+type Hash []byte
+
+//MarshalJSON renders the hash as hex
+func (h Hash) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hex.EncodeToString(h))
+}
+
+//Transaction is a decoded committed transaction
+type Transaction struct {
+	TxID           string              `json:"txId"`
+	ChannelID      string              `json:"channelId"`
+	Timestamp      time.Time           `json:"timestamp"`
+	Creator        string              `json:"creator"`
+	ValidationCode string              `json:"validationCode"`
+	BlockNumber    uint64              `json:"blockNumber"`
+	Actions        []TransactionAction `json:"actions"`
+}
+
+//TransactionAction is a decoded chaincode action of a transaction
+type TransactionAction struct {
+	ChaincodeID string `json:"chaincodeId"`
+	//Endorsers are the MSP IDs of the endorsers of the action
+	Endorsers []string      `json:"endorsers"`
+	Status    int32         `json:"status"`
+	Message   string        `json:"message,omitempty"`
+	Payload   Bytes         `json:"payload,omitempty"`
+	RWSet     *ReadWriteSet `json:"rwset"`
+	//Event is the chaincode event emitted by the action, nil if none
+	Event *ChaincodeEvent `json:"event,omitempty"`
+}
+
+//JSON renders the transaction as indented JSON
+func (t *Transaction) JSON() ([]byte, error) {
+	return renderJSON(t)
+}
+
+//JSON renders the read/write set as indented JSON
+func (rw *ReadWriteSet) JSON() ([]byte, error) {
+	return renderJSON(rw)
+}
+
+//Endorsements decodes the proposal responses of the endorsers of the result
+func (r *Result) Endorsements() ([]SimulatedEndorsement, error) {
+	var endorsements []SimulatedEndorsement
+	for _, response := range r.Responses {
+		endorsement, err := DecodeEndorsement(response)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to decode proposal response from peer "+response.Endorser)
+		}
+		endorsements = append(endorsements, endorsement)
+	}
+	return endorsements, nil
+}
+
+//InspectTransaction fetches the committed transaction from a client org peer and decodes it, e.g. to explain an MVCC conflict
+func InspectTransaction(provider providers.FabricNetworkClientProvider, channelID string, txID string) (*Transaction, error) {
+	if provider == nil {
+		return nil, errors.Errorf("Fabric network client provider is not set.")
+	}
+	ledgerClient, err := provider.LedgerClient(channelID)
+	if err != nil {
+		return nil, err
+	}
+	processedTx, err := ledgerClient.QueryTransaction(fab.TransactionID(txID))
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to query transaction "+txID)
+	}
+	tx, err := DecodeTransaction(processedTx.TransactionEnvelope)
+	if err != nil {
+		return nil, err
+	}
+	tx.ValidationCode = pb.TxValidationCode(processedTx.ValidationCode).String()
+	block, err := ledgerClient.QueryBlockByTxID(fab.TransactionID(txID))
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to query block of transaction "+txID)
+	}
+	tx.BlockNumber = block.Header.Number
+	return tx, nil
+}
+
+//DecodeTransaction decodes the envelope of an endorser transaction
+func DecodeTransaction(envelope *common.Envelope) (*Transaction, error) {
+	if envelope == nil {
+		return nil, errors.New("transaction envelope is not set")
+	}
+	payload := new(common.Payload)
+	if err := proto.Unmarshal(envelope.Payload, payload); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal transaction payload")
+	}
+	if payload.Header == nil {
+		return nil, errors.New("transaction header is not set")
+	}
+	channelHeader := new(common.ChannelHeader)
+	if err := proto.Unmarshal(payload.Header.ChannelHeader, channelHeader); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal channel header")
+	}
+	if common.HeaderType(channelHeader.Type) != common.HeaderType_ENDORSER_TRANSACTION {
+		return nil, errors.Errorf("transaction %s is a %s transaction, not an endorser transaction", channelHeader.TxId, common.HeaderType(channelHeader.Type))
+	}
+	signatureHeader := new(common.SignatureHeader)
+	if err := proto.Unmarshal(payload.Header.SignatureHeader, signatureHeader); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal signature header")
+	}
+
+	tx := new(Transaction)
+	tx.TxID = channelHeader.TxId
+	tx.ChannelID = channelHeader.ChannelId
+	if channelHeader.Timestamp != nil {
+		tx.Timestamp = time.Unix(channelHeader.Timestamp.Seconds, int64(channelHeader.Timestamp.Nanos)).UTC()
+	}
+	tx.Creator = identityMSPID(signatureHeader.Creator)
+
+	transaction := new(pb.Transaction)
+	if err := proto.Unmarshal(payload.Data, transaction); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal transaction")
+	}
+	for i, txAction := range transaction.Actions {
+		action, err := decodeTransactionAction(txAction)
+		if err != nil {
+			return nil, errors.WithMessage(err, fmt.Sprintf("failed to decode action %d of transaction %s", i, tx.TxID))
+		}
+		tx.Actions = append(tx.Actions, action)
+	}
+	return tx, nil
+}
+
+func decodeTransactionAction(txAction *pb.TransactionAction) (TransactionAction, error) {
+	var action TransactionAction
+	actionPayload := new(pb.ChaincodeActionPayload)
+	if err := proto.Unmarshal(txAction.Payload, actionPayload); err != nil {
+		return action, errors.Wrap(err, "failed to unmarshal chaincode action payload")
+	}
+	if actionPayload.Action == nil {
+		return action, errors.New("chaincode endorsed action is not set")
+	}
+	for _, endorsement := range actionPayload.Action.Endorsements {
+		action.Endorsers = append(action.Endorsers, identityMSPID(endorsement.Endorser))
+	}
+	prp := new(pb.ProposalResponsePayload)
+	if err := proto.Unmarshal(actionPayload.Action.ProposalResponsePayload, prp); err != nil {
+		return action, errors.Wrap(err, "failed to unmarshal proposal response payload")
+	}
+	ccAction := new(pb.ChaincodeAction)
+	if err := proto.Unmarshal(prp.Extension, ccAction); err != nil {
+		return action, errors.Wrap(err, "failed to unmarshal chaincode action")
+	}
+	if ccAction.ChaincodeId != nil {
+		action.ChaincodeID = ccAction.ChaincodeId.Name
+	}
+	if ccAction.Response != nil {
+		action.Status = ccAction.Response.Status
+		action.Message = ccAction.Response.Message
+		action.Payload = ccAction.Response.Payload
+	}
+	var err error
+	if action.RWSet, err = decodeReadWriteSet(ccAction.Results); err != nil {
+		return action, err
+	}
+	if action.Event, err = decodeChaincodeEvent(ccAction.Events); err != nil {
+		return action, err
+	}
+	return action, nil
+}
+
+//identityMSPID returns the MSP ID of the serialized identity, empty if it cannot be decoded
+func identityMSPID(serializedIdentity []byte) string {
+	identity := new(msp.SerializedIdentity)
+	if err := proto.Unmarshal(serializedIdentity, identity); err != nil {
+		return ""
+	}
+	return identity.Mspid
+}
+
+func renderJSON(v interface{}) ([]byte, error) {
+	raw, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to render JSON")
+	}
+	return raw, nil
+}
@@ -0,0 +1,150 @@
+package chaincode
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/ledger/rwset/kvrwset"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/msp"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+func marshalTestProto(t *testing.T, msg proto.Message) []byte {
+	raw, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func marshalTestIdentity(t *testing.T, mspID string) []byte {
+	return marshalTestProto(t, &msp.SerializedIdentity{Mspid: mspID, IdBytes: []byte("cert")})
+}
+
+//newTestEnvelope returns the envelope of a transaction of the header type created by Org1MSP
+func newTestEnvelope(t *testing.T, headerType common.HeaderType, actions ...*pb.TransactionAction) *common.Envelope {
+	channelHeader := &common.ChannelHeader{
+		Type:      int32(headerType),
+		ChannelId: "mychannel",
+		TxId:      "tx1",
+		Timestamp: &timestamp.Timestamp{Seconds: 1600000000, Nanos: 5},
+	}
+	header := &common.Header{
+		ChannelHeader:   marshalTestProto(t, channelHeader),
+		SignatureHeader: marshalTestProto(t, &common.SignatureHeader{Creator: marshalTestIdentity(t, "Org1MSP"), Nonce: []byte("nonce")}),
+	}
+	data := marshalTestProto(t, &pb.Transaction{Actions: actions})
+	return &common.Envelope{Payload: marshalTestProto(t, &common.Payload{Header: header, Data: data})}
+}
+
+//newTestTransactionAction returns a transaction action of the chaincode endorsed by the MSPs
+func newTestTransactionAction(t *testing.T, ccAction *pb.ChaincodeAction, mspIDs ...string) *pb.TransactionAction {
+	prp := marshalTestProto(t, &pb.ProposalResponsePayload{Extension: marshalTestProto(t, ccAction)})
+	endorsedAction := &pb.ChaincodeEndorsedAction{ProposalResponsePayload: prp}
+	for _, mspID := range mspIDs {
+		endorsedAction.Endorsements = append(endorsedAction.Endorsements, &pb.Endorsement{Endorser: marshalTestIdentity(t, mspID)})
+	}
+	return &pb.TransactionAction{Payload: marshalTestProto(t, &pb.ChaincodeActionPayload{Action: endorsedAction})}
+}
+
+func TestBytesJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		value Bytes
+		want  string
+	}{
+		{name: "text", value: Bytes("hello"), want: `{"base64":"aGVsbG8=","text":"hello"}`},
+		{name: "binary", value: Bytes{0xff, 0x00}, want: `{"base64":"/wA="}`},
+		{name: "empty", value: nil, want: `{"base64":"","text":""}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			raw, err := json.Marshal(test.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(raw) != test.want {
+				t.Errorf("expected %s, got %s", test.want, raw)
+			}
+			var decoded Bytes
+			if err := json.Unmarshal(raw, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(decoded, test.value) {
+				t.Errorf("expected %q once decoded, got %q", test.value, decoded)
+			}
+		})
+	}
+}
+
+func TestHashJSON(t *testing.T) {
+	raw, err := json.Marshal(Hash{0xca, 0xfe})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw) != `"cafe"` {
+		t.Errorf(`expected "cafe", got %s`, raw)
+	}
+}
+
+func TestDecodeTransaction(t *testing.T) {
+	results := marshalTestRWSet(t, testNamespace{name: "assets", kvRWSet: &kvrwset.KVRWSet{
+		Reads:  []*kvrwset.KVRead{{Key: "asset1", Version: &kvrwset.Version{BlockNum: 4, TxNum: 1}}},
+		Writes: []*kvrwset.KVWrite{{Key: "asset1", Value: []byte("2")}},
+	}})
+	ccAction := &pb.ChaincodeAction{
+		Results:     results,
+		Events:      marshalTestProto(t, &pb.ChaincodeEvent{ChaincodeId: "assets", TxId: "tx1", EventName: "AssetUpdated", Payload: []byte("asset1")}),
+		Response:    &pb.Response{Status: 200, Payload: []byte("ok")},
+		ChaincodeId: &pb.ChaincodeID{Name: "assets", Version: "1.0"},
+	}
+	want := &Transaction{
+		TxID:      "tx1",
+		ChannelID: "mychannel",
+		Timestamp: time.Unix(1600000000, 5).UTC(),
+		Creator:   "Org1MSP",
+		Actions: []TransactionAction{{
+			ChaincodeID: "assets",
+			Endorsers:   []string{"Org1MSP", "Org2MSP"},
+			Status:      200,
+			Payload:     Bytes("ok"),
+			RWSet: &ReadWriteSet{Namespaces: []NamespaceReadWriteSet{{
+				Namespace: "assets",
+				Reads:     []KVRead{{Key: "asset1", Version: &Version{BlockNum: 4, TxNum: 1}}},
+				Writes:    []KVWrite{{Key: "asset1", Value: Bytes("2")}},
+			}}},
+			Event: &ChaincodeEvent{ChaincodeID: "assets", TxID: "tx1", EventName: "AssetUpdated", Payload: Bytes("asset1")},
+		}},
+	}
+	tx, err := DecodeTransaction(newTestEnvelope(t, common.HeaderType_ENDORSER_TRANSACTION, newTestTransactionAction(t, ccAction, "Org1MSP", "Org2MSP")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tx, want) {
+		t.Errorf("expected %+v, got %+v", want, tx)
+	}
+
+	tests := []struct {
+		name     string
+		envelope *common.Envelope
+	}{
+		{name: "no envelope"},
+		{name: "no header", envelope: &common.Envelope{Payload: marshalTestProto(t, &common.Payload{})}},
+		{name: "config transaction", envelope: newTestEnvelope(t, common.HeaderType_CONFIG)},
+		{name: "no endorsed action", envelope: newTestEnvelope(t, common.HeaderType_ENDORSER_TRANSACTION, &pb.TransactionAction{Payload: marshalTestProto(t, &pb.ChaincodeActionPayload{})})},
+		{name: "invalid action payload", envelope: newTestEnvelope(t, common.HeaderType_ENDORSER_TRANSACTION, &pb.TransactionAction{Payload: []byte{0xff}})},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if tx, err := DecodeTransaction(test.envelope); err == nil {
+				t.Errorf("expected an error, got %+v", tx)
+			}
+		})
+	}
+}
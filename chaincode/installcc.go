@@ -1,19 +1,18 @@
 package chaincode
 
 import (
-	"fmt"
 	"net/http"
 	"os"
+	"time"
 
 	"dendrix.io/fabricsdk/providers"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/ccpackager/gopackager"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
 	"github.com/pkg/errors"
 )
 
-const admin = "Admin"
-
 type installChaincodeClient struct {
 	//To indicate that this interface is implemented
 	ChaincodeClient
@@ -21,28 +20,54 @@ type installChaincodeClient struct {
 	chaincodeID      string
 	chaincodePath    string
 	chaincodeVersion string
+	targets          []fab.Peer
+	timeout          time.Duration
 }
 
 //NewInstallClient returns a ChaincodeClient implmentation for installing chaincode on the network peers
 func NewInstallClient(provider providers.FabricNetworkClientProvider, chaincodeID string, chaincodeVersion string, chaincodePath string) (ChaincodeClient, error) {
+	req := InstallRequest{
+		ChaincodeID:      chaincodeID,
+		ChaincodeVersion: chaincodeVersion,
+		ChaincodePath:    chaincodePath,
+	}
+	return NewInstallClientFromRequest(provider, req)
+}
+
+//NewInstallClientFromRequest returns a ChaincodeClient implmentation for installing the requested chaincode.
+//Unless targets are set in the options, the chaincode is installed on the peers of every participating org.
+func NewInstallClientFromRequest(provider providers.FabricNetworkClientProvider, req InstallRequest, opts ...Option) (ChaincodeClient, error) {
 	//Verify that the provider is not nil
 	if provider == nil {
 		return nil, errors.Errorf("Fabric network client provider is not set.")
 	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	options, err := NewOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := options.Validate(InstallRequestType); err != nil {
+		return nil, err
+	}
 	i := new(installChaincodeClient)
 	i.FabricNetworkClientProvider = provider
-	i.chaincodeID = chaincodeID
-	i.chaincodeVersion = chaincodeVersion
-	i.chaincodePath = chaincodePath
+	i.chaincodeID = req.ChaincodeID
+	i.chaincodeVersion = req.ChaincodeVersion
+	i.chaincodePath = req.ChaincodePath
+	i.targets = options.Targets
+	i.timeout = options.Timeout
 	return i, nil
 }
 
-func (ic *installChaincodeClient) installChaincode(orgID string, targets []fab.Peer) error {
+//installChaincode installs the chaincode on the org targets and returns the outcome on each of them
+func (ic *installChaincodeClient) installChaincode(orgID string, targets []fab.Peer) ([]PeerStatus, error) {
 	//Package chaincode
 	goPath := os.Getenv("GOPATH")
 	ccPkg, err := gopackager.NewCCPackage(ic.chaincodePath, goPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	//Create InstallCCRequest instance
 	req := resmgmt.InstallCCRequest{
@@ -53,51 +78,85 @@ func (ic *installChaincodeClient) installChaincode(orgID string, targets []fab.P
 	}
 
 	//Resmgmt.Client instance
-	resMgmtClient, err := ic.ResourceMgmtClientByOrg(admin, orgID)
+	resMgmtClient, err := ic.ResourceMgmtClientByOrgAdmin(orgID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	//Invoke InstallCC for each org id
-	responses, err := resMgmtClient.InstallCC(req, resmgmt.WithTargets(targets...))
+	responses, err := resMgmtClient.InstallCC(req, resmgmtRequestOptions(targets, ic.timeout)...)
 	if err != nil {
-		return errors.Errorf("InstallChaincode returned error: %v", err)
+		return nil, errors.Errorf("InstallChaincode returned error: %v", err)
 	}
 
-	ccIDVersion := ic.chaincodeID + "." + ic.chaincodeVersion
-
 	var errs []error
+	var statuses []PeerStatus
 	for _, resp := range responses {
-		if resp.Info == "already installed" {
-			fmt.Printf("Chaincode %s already installed on peer: %s.\n", ccIDVersion, resp.Target)
-		} else if resp.Status != http.StatusOK {
+		switch {
+		case resp.Info == "already installed":
+			statuses = append(statuses, PeerStatus{Peer: resp.Target, Info: InfoExists})
+		case resp.Status != http.StatusOK:
+			statuses = append(statuses, PeerStatus{Peer: resp.Target, Info: InfoFailed, Message: resp.Info})
 			errs = append(errs, errors.Errorf("installCC returned error from peer %s: %s", resp.Target, resp.Info))
-		} else {
-			fmt.Printf("...successfuly installed chaincode %s on peer %s.\n", ccIDVersion, resp.Target)
+		default:
+			statuses = append(statuses, PeerStatus{Peer: resp.Target, Info: InfoOK})
 		}
 	}
 
 	if len(errs) > 0 {
-		fmt.Printf("\n Errors returned from InstallCC: %v \n", errs)
-		return errs[0]
+		return statuses, errs[0]
 	}
 
-	return nil
+	return statuses, nil
 }
 
 func (ic *installChaincodeClient) Invoke() ([]byte, error) {
+	result, err := ic.InvokeResult()
+	return result.Payload, err
+}
+
+//InvokeResult installs the chaincode and returns the peers it is installed on as the result endorsers,
+//and the outcome on each target peer as the result peers. The result is returned along with the last install error, if any.
+func (ic *installChaincodeClient) InvokeResult() (*Result, error) {
+	result := new(Result)
+	result.ValidationCode = pb.TxValidationCode_NOT_VALIDATED
+	result.Payload = []byte{0x00}
+	result.Info = InfoOK
 	var lastErr error
 	for orgID, peers := range ic.PeersByOrgID() {
-		fmt.Printf("Installing chaincode %s on org[%s] peers:\n", ic.chaincodeID, orgID)
-		for _, peer := range peers {
-			fmt.Printf("-- %s\n", peer.URL())
+		peers = ic.filterTargets(peers)
+		if len(peers) == 0 {
+			continue
+		}
+		statuses, err := ic.installChaincode(orgID, peers)
+		result.Peers = append(result.Peers, statuses...)
+		for _, status := range statuses {
+			if status.Info != InfoFailed {
+				result.Endorsers = append(result.Endorsers, status.Peer)
+			}
 		}
-		err := ic.installChaincode(orgID, peers)
 		if err != nil {
 			lastErr = err
 		}
 	}
 
-	return []byte{0x00}, lastErr
+	return result, lastErr
+}
+
+//filterTargets returns the org peers that are among the targets set in the options, all org peers if none is set
+func (ic *installChaincodeClient) filterTargets(peers []fab.Peer) []fab.Peer {
+	if len(ic.targets) == 0 {
+		return peers
+	}
+	var filtered []fab.Peer
+	for _, peer := range peers {
+		for _, target := range ic.targets {
+			if peer.URL() == target.URL() {
+				filtered = append(filtered, peer)
+				break
+			}
+		}
+	}
+	return filtered
 }
 
 func (ic *installChaincodeClient) Terminate() {
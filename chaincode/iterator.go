@@ -0,0 +1,212 @@
+package chaincode
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"dendrix.io/fabricsdk/providers"
+	"github.com/pkg/errors"
+)
+
+//PageArgs returns the arguments of the query of a page, given the page size and the bookmark of the previous page
+type PageArgs func(args [][]byte, pageSize int32, bookmark string) [][]byte
+
+//PageDecoder decodes the items and the bookmark of the next page from the payload of a page
+type PageDecoder func(payload []byte) (items []json.RawMessage, bookmark string, err error)
+
+//PageQuery defines a paginated query of a chaincode function
+type PageQuery struct {
+	InvokeRequest
+	PageSize int32
+	//Bookmark is the bookmark of the first page, empty to start from the first result
+	Bookmark string
+	//MaxItems is the maximum number of items returned by the iterator, 0 for no limit
+	MaxItems int
+	//PageArgs defaults to AppendPageArgs
+	PageArgs PageArgs
+	//Decoder defaults to JSONPageDecoder("records", "bookmark")
+	Decoder PageDecoder
+}
+
+//Validate checks that the required fields of the query are set and the page size and maximum number of items are valid
+func (query PageQuery) Validate() error {
+	if err := query.InvokeRequest.Validate(); err != nil {
+		return err
+	}
+	if query.PageSize <= 0 {
+		return errors.Errorf("invalid page size %d", query.PageSize)
+	}
+	if query.MaxItems < 0 {
+		return errors.Errorf("invalid maximum number of items %d", query.MaxItems)
+	}
+	return nil
+}
+
+//AppendPageArgs appends the page size and the bookmark to the arguments of the query
+func AppendPageArgs(args [][]byte, pageSize int32, bookmark string) [][]byte {
+	pageArgs := make([][]byte, 0, len(args)+2)
+	pageArgs = append(pageArgs, args...)
+	return append(pageArgs, []byte(strconv.FormatInt(int64(pageSize), 10)), []byte(bookmark))
+}
+
+//JSONPageDecoder returns a decoder of pages returned as a JSON object holding the items and the bookmark under the fields
+func JSONPageDecoder(itemsField string, bookmarkField string) PageDecoder {
+	return func(payload []byte) ([]json.RawMessage, string, error) {
+		var page map[string]json.RawMessage
+		if err := json.Unmarshal(payload, &page); err != nil {
+			return nil, "", errors.Wrap(err, "failed to unmarshal page")
+		}
+		var items []json.RawMessage
+		if raw, found := page[itemsField]; found && string(raw) != "null" {
+			if err := json.Unmarshal(raw, &items); err != nil {
+				return nil, "", errors.Wrapf(err, "failed to unmarshal page field %s", itemsField)
+			}
+		}
+		var bookmark string
+		if raw, found := page[bookmarkField]; found && string(raw) != "null" {
+			if err := json.Unmarshal(raw, &bookmark); err != nil {
+				return nil, "", errors.Wrapf(err, "failed to unmarshal page field %s", bookmarkField)
+			}
+		}
+		return items, bookmark, nil
+	}
+}
+
+//QueryIterator iterates over the items of a paginated query, evaluating the next page once the items of the current page are consumed.
+//It is not safe for concurrent use.
+type QueryIterator struct {
+	ctx      context.Context
+	provider providers.FabricNetworkClientProvider
+	query    PageQuery
+	closed   bool
+	items    []json.RawMessage
+	item     json.RawMessage
+	bookmark string
+	count    int
+	done     bool
+	err      error
+	//evaluate evaluates the query of a page, with the query client unless replaced by the tests
+	evaluate func(req InvokeRequest) (*Result, error)
+}
+
+//NewQueryIterator returns an iterator over the items of the paginated query, evaluated with the options on the client provider.
+//The iterator stops once the bookmark is exhausted, the maximum number of items is reached or the context is done.
+func NewQueryIterator(ctx context.Context, provider providers.FabricNetworkClientProvider, query PageQuery, opts ...Option) (*QueryIterator, error) {
+	if provider == nil {
+		return nil, errors.Errorf("Fabric network client provider is not set.")
+	}
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	options, err := NewOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := options.Validate(QueryRequestType); err != nil {
+		return nil, err
+	}
+	return newQueryIterator(ctx, provider, query, options), nil
+}
+
+func newQueryIterator(ctx context.Context, provider providers.FabricNetworkClientProvider, query PageQuery, options Options) *QueryIterator {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if query.PageArgs == nil {
+		query.PageArgs = AppendPageArgs
+	}
+	if query.Decoder == nil {
+		query.Decoder = JSONPageDecoder("records", "bookmark")
+	}
+	options.Context = ctx
+	it := new(QueryIterator)
+	it.ctx = ctx
+	it.provider = provider
+	it.query = query
+	it.bookmark = query.Bookmark
+	it.evaluate = func(req InvokeRequest) (*Result, error) {
+		return newQueryClient(provider, req, options).InvokeResult()
+	}
+	return it
+}
+
+//Next advances to the next item, evaluating the next page if needed. It returns false once the iteration is over or failed.
+func (it *QueryIterator) Next() bool {
+	if it.err != nil || (it.query.MaxItems > 0 && it.count >= it.query.MaxItems) {
+		return false
+	}
+	for len(it.items) == 0 {
+		if it.done {
+			return false
+		}
+		if err := it.ctx.Err(); err != nil {
+			it.err = err
+			return false
+		}
+		if err := it.fetch(); err != nil {
+			it.err = err
+			return false
+		}
+	}
+	it.item, it.items = it.items[0], it.items[1:]
+	it.count++
+	return true
+}
+
+//fetch evaluates the page of the current bookmark
+func (it *QueryIterator) fetch() error {
+	req := it.query.InvokeRequest
+	req.Args = it.query.PageArgs(req.Args, it.query.PageSize, it.bookmark)
+	result, err := it.evaluate(req)
+	if err != nil {
+		return err
+	}
+	items, bookmark, err := it.query.Decoder(result.Payload)
+	if err != nil {
+		return errors.WithMessage(err, "failed to decode page of function "+req.Fcn)
+	}
+	//The bookmark is exhausted once a page is not full or the bookmark does not change
+	if len(items) < int(it.query.PageSize) || bookmark == "" || bookmark == it.bookmark {
+		it.done = true
+	}
+	it.items = items
+	it.bookmark = bookmark
+	return nil
+}
+
+//Item returns the current item
+func (it *QueryIterator) Item() json.RawMessage {
+	return it.item
+}
+
+//Decode unmarshals the current JSON item into v
+func (it *QueryIterator) Decode(v interface{}) error {
+	if err := json.Unmarshal(it.item, v); err != nil {
+		return errors.Wrap(err, "failed to unmarshal item")
+	}
+	return nil
+}
+
+//Bookmark returns the bookmark of the next page, which can be used to resume the iteration later
+func (it *QueryIterator) Bookmark() string {
+	return it.bookmark
+}
+
+//Count returns the number of items iterated so far
+func (it *QueryIterator) Count() int {
+	return it.count
+}
+
+//Err returns the error that stopped the iteration, if any
+func (it *QueryIterator) Err() error {
+	return it.err
+}
+
+//Close closes the client provider of the iterator, as Terminate does for the chaincode clients
+func (it *QueryIterator) Close() {
+	if !it.closed {
+		it.closed = true
+		it.provider.CloseSDK()
+	}
+}
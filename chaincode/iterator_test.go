@@ -0,0 +1,172 @@
+package chaincode
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+//testPage is a page served by the test query, keyed by the bookmark it is queried with
+type testPage struct {
+	items    []string
+	bookmark string
+}
+
+//newTestQueryIterator returns an iterator over the pages, counting the pages evaluated
+func newTestQueryIterator(ctx context.Context, query PageQuery, pages map[string]testPage, evaluated *int) *QueryIterator {
+	it := newQueryIterator(ctx, nil, query, Options{})
+	it.evaluate = func(req InvokeRequest) (*Result, error) {
+		*evaluated++
+		bookmark := string(req.Args[len(req.Args)-1])
+		page, found := pages[bookmark]
+		if !found {
+			return nil, errors.Errorf("unexpected bookmark %q", bookmark)
+		}
+		records := make([]json.RawMessage, len(page.items))
+		for i, item := range page.items {
+			records[i] = json.RawMessage(strconv.Quote(item))
+		}
+		payload, err := json.Marshal(map[string]interface{}{"records": records, "bookmark": page.bookmark})
+		return &Result{Payload: payload}, err
+	}
+	return it
+}
+
+func TestQueryIterator(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxItems  int
+		pages     map[string]testPage
+		items     []string
+		evaluated int
+	}{
+		{
+			name:      "last page not full",
+			pages:     map[string]testPage{"": {items: []string{"a", "b"}, bookmark: "1"}, "1": {items: []string{"c"}, bookmark: "2"}},
+			items:     []string{"a", "b", "c"},
+			evaluated: 2,
+		},
+		{
+			name:      "empty last page",
+			pages:     map[string]testPage{"": {items: []string{"a", "b"}, bookmark: "1"}, "1": {bookmark: "2"}},
+			items:     []string{"a", "b"},
+			evaluated: 2,
+		},
+		{
+			name:      "repeated bookmark",
+			pages:     map[string]testPage{"": {items: []string{"a", "b"}, bookmark: "1"}, "1": {items: []string{"c", "d"}, bookmark: "1"}},
+			items:     []string{"a", "b", "c", "d"},
+			evaluated: 2,
+		},
+		{
+			name:      "empty bookmark",
+			pages:     map[string]testPage{"": {items: []string{"a", "b"}}},
+			items:     []string{"a", "b"},
+			evaluated: 1,
+		},
+		{
+			name:      "maximum number of items",
+			maxItems:  3,
+			pages:     map[string]testPage{"": {items: []string{"a", "b"}, bookmark: "1"}, "1": {items: []string{"c", "d"}, bookmark: "2"}},
+			items:     []string{"a", "b", "c"},
+			evaluated: 2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			evaluated := 0
+			query := PageQuery{InvokeRequest: InvokeRequest{ChannelID: "mychannel", ChaincodeID: "assets", Fcn: "QueryAssets"}, PageSize: 2, MaxItems: test.maxItems}
+			it := newTestQueryIterator(context.Background(), query, test.pages, &evaluated)
+			var items []string
+			for it.Next() {
+				var item string
+				if err := it.Decode(&item); err != nil {
+					t.Fatal(err)
+				}
+				items = append(items, item)
+			}
+			if err := it.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(items, test.items) {
+				t.Errorf("expected items %v, got %v", test.items, items)
+			}
+			if evaluated != test.evaluated {
+				t.Errorf("expected %d pages evaluated, got %d", test.evaluated, evaluated)
+			}
+			if it.Count() != len(test.items) {
+				t.Errorf("expected count %d, got %d", len(test.items), it.Count())
+			}
+		})
+	}
+}
+
+func TestQueryIteratorCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	evaluated := 0
+	pages := map[string]testPage{"": {items: []string{"a", "b"}, bookmark: "1"}, "1": {items: []string{"c", "d"}, bookmark: "2"}}
+	query := PageQuery{InvokeRequest: InvokeRequest{ChannelID: "mychannel", ChaincodeID: "assets", Fcn: "QueryAssets"}, PageSize: 2}
+	it := newTestQueryIterator(ctx, query, pages, &evaluated)
+	for i := 0; i < 2; i++ {
+		if !it.Next() {
+			t.Fatalf("expected item %d, got error %v", i, it.Err())
+		}
+	}
+	cancel()
+	if it.Next() {
+		t.Fatal("expected the iteration to stop once the context is done")
+	}
+	if it.Err() != context.Canceled {
+		t.Errorf("expected the context error, got %v", it.Err())
+	}
+	if evaluated != 1 {
+		t.Errorf("expected 1 page evaluated, got %d", evaluated)
+	}
+	if it.Bookmark() != "1" {
+		t.Errorf("expected the bookmark of the next page to resume from, got %q", it.Bookmark())
+	}
+}
+
+func TestJSONPageDecoder(t *testing.T) {
+	tests := []struct {
+		name     string
+		decoder  PageDecoder
+		payload  string
+		items    []string
+		bookmark string
+		err      bool
+	}{
+		{name: "items and bookmark", decoder: JSONPageDecoder("records", "bookmark"), payload: `{"records":[{"id":"a"},"b"],"bookmark":"next"}`, items: []string{`{"id":"a"}`, `"b"`}, bookmark: "next"},
+		{name: "custom fields", decoder: JSONPageDecoder("assets", "next"), payload: `{"assets":[1,2],"next":"2"}`, items: []string{"1", "2"}, bookmark: "2"},
+		{name: "null fields", decoder: JSONPageDecoder("records", "bookmark"), payload: `{"records":null,"bookmark":null}`},
+		{name: "missing fields", decoder: JSONPageDecoder("records", "bookmark"), payload: `{}`},
+		{name: "not an object", decoder: JSONPageDecoder("records", "bookmark"), payload: `[1,2]`, err: true},
+		{name: "items not a list", decoder: JSONPageDecoder("records", "bookmark"), payload: `{"records":{"id":"a"}}`, err: true},
+		{name: "bookmark not a string", decoder: JSONPageDecoder("records", "bookmark"), payload: `{"records":[],"bookmark":2}`, err: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			items, bookmark, err := test.decoder([]byte(test.payload))
+			if test.err {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, item := range items {
+				got = append(got, string(item))
+			}
+			if !reflect.DeepEqual(got, test.items) || bookmark != test.bookmark {
+				t.Errorf("expected items %v and bookmark %q, got %v and %q", test.items, test.bookmark, got, bookmark)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+//MetadataFunction is the system function of the contract API chaincodes returning their metadata
+const MetadataFunction = "org.hyperledger.fabric:GetMetadata"
+
+//ChaincodeMetadata is the metadata of a contract API chaincode: its contracts, their transactions and the schemas they use
+type ChaincodeMetadata struct {
+	Info       *InfoMetadata               `json:"info,omitempty"`
+	Contracts  map[string]ContractMetadata `json:"contracts"`
+	Components ComponentMetadata           `json:"components"`
+}
+
+//InfoMetadata describes a chaincode or a contract
+type InfoMetadata struct {
+	Title       string `json:"title,omitempty"`
+	Version     string `json:"version,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+//ContractMetadata describes a contract of the chaincode
+type ContractMetadata struct {
+	Name         string                `json:"name"`
+	Info         *InfoMetadata         `json:"info,omitempty"`
+	Default      bool                  `json:"default,omitempty"`
+	Transactions []TransactionMetadata `json:"transactions"`
+}
+
+//TransactionMetadata describes a transaction of a contract
+type TransactionMetadata struct {
+	Name       string              `json:"name"`
+	Tags       []string            `json:"tag,omitempty"`
+	Parameters []ParameterMetadata `json:"parameters,omitempty"`
+	//Returns is the schema of the returned value, nil if the transaction returns nothing
+	Returns *Schema `json:"returns,omitempty"`
+}
+
+//ParameterMetadata describes a parameter of a transaction
+type ParameterMetadata struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+//ComponentMetadata holds the schemas referenced by the transactions
+type ComponentMetadata struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
+
+//Schema is the JSON schema of a parameter, a returned value or a component
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties json.RawMessage    `json:"additionalProperties,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+}
+
+//ParseMetadata parses the metadata returned by the GetMetadata function of a contract API chaincode
+func ParseMetadata(raw []byte) (*ChaincodeMetadata, error) {
+	metadata := new(ChaincodeMetadata)
+	if err := json.Unmarshal(raw, metadata); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal chaincode metadata")
+	}
+	for name, contract := range metadata.Contracts {
+		if contract.Name == "" {
+			contract.Name = name
+			metadata.Contracts[name] = contract
+		}
+	}
+	return metadata, nil
+}
+
+//UnmarshalJSON accepts the returned value both as a schema and as a list holding a named schema, as returned by the Node.js contract API
+func (tx *TransactionMetadata) UnmarshalJSON(data []byte) error {
+	type transactionMetadata TransactionMetadata
+	var decoded struct {
+		transactionMetadata
+		Returns json.RawMessage `json:"returns,omitempty"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*tx = TransactionMetadata(decoded.transactionMetadata)
+	tx.Returns = nil
+	returns := strings.TrimSpace(string(decoded.Returns))
+	switch {
+	case returns == "" || returns == "null":
+	case strings.HasPrefix(returns, "["):
+		var named []ParameterMetadata
+		if err := json.Unmarshal(decoded.Returns, &named); err != nil {
+			return err
+		}
+		if len(named) > 0 {
+			tx.Returns = named[0].Schema
+		}
+	default:
+		tx.Returns = new(Schema)
+		if err := json.Unmarshal(decoded.Returns, tx.Returns); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//IsSubmit reports whether the transaction is tagged to be submitted. Transactions without a submit or evaluate tag are submitted.
+func (tx TransactionMetadata) IsSubmit() bool {
+	for _, tag := range tx.Tags {
+		tag = strings.ToLower(tag)
+		if strings.HasPrefix(tag, "evaluate") {
+			return false
+		}
+		if strings.HasPrefix(tag, "submit") {
+			return true
+		}
+	}
+	return true
+}
+
+//QualifiedName returns the name the transaction is invoked with, prefixed by its contract name
+func (tx TransactionMetadata) QualifiedName(contractName string) string {
+	return contractName + ":" + tx.Name
+}
+
+//Resolve returns the component schema the schema references, the schema itself if it is not a reference
+func (metadata *ChaincodeMetadata) Resolve(schema *Schema) (*Schema, error) {
+	if schema == nil || schema.Ref == "" {
+		return schema, nil
+	}
+	name := SchemaRefName(schema.Ref)
+	component, found := metadata.Components.Schemas[name]
+	if !found {
+		return nil, errors.Errorf("schema %s is not defined", schema.Ref)
+	}
+	return component, nil
+}
+
+//SchemaRefName returns the name of the component schema of the reference, e.g. Asset for #/components/schemas/Asset
+func SchemaRefName(ref string) string {
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
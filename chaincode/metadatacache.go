@@ -0,0 +1,345 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"dendrix.io/fabricsdk/providers"
+	"github.com/pkg/errors"
+)
+
+//MetadataCache caches the contract metadata of the chaincodes, fetched once per channel and chaincode.
+//It is safe for concurrent use, concurrent gets of metadata not cached yet sharing a single fetch.
+type MetadataCache struct {
+	mutex     sync.Mutex
+	metadatas map[string]*ChaincodeMetadata
+	fetches   map[string]*metadataFetch
+}
+
+//metadataFetch is a fetch of the metadata of a chaincode, awaited by the gets of its key while in flight
+type metadataFetch struct {
+	done     chan struct{}
+	metadata *ChaincodeMetadata
+	err      error
+}
+
+//NewMetadataCache returns an empty metadata cache
+func NewMetadataCache() *MetadataCache {
+	return &MetadataCache{metadatas: make(map[string]*ChaincodeMetadata), fetches: make(map[string]*metadataFetch)}
+}
+
+//WithMetadataValidation checks the function and arguments of execute and query requests against the contract metadata
+//of the chaincode before sending the proposal. The metadata is fetched once and kept in the cache.
+func WithMetadataValidation(cache *MetadataCache) Option {
+	return func(opts *Options) error {
+		if cache == nil {
+			return errors.New("metadata cache is not set")
+		}
+		opts.MetadataCache = cache
+		return nil
+	}
+}
+
+func metadataKey(channelID string, chaincodeID string) string {
+	return channelID + "/" + chaincodeID
+}
+
+//Get returns the metadata of the chaincode, fetching it with the client provider if it is not cached
+func (c *MetadataCache) Get(provider providers.FabricNetworkClientProvider, channelID string, chaincodeID string) (*ChaincodeMetadata, error) {
+	return c.get(metadataKey(channelID, chaincodeID), func() (*ChaincodeMetadata, error) {
+		req := InvokeRequest{ChannelID: channelID, ChaincodeID: chaincodeID, Fcn: MetadataFunction}
+		payload, err := newQueryClient(provider, req, Options{}).Invoke()
+		if err != nil {
+			return nil, errors.WithMessage(err, fmt.Sprintf("failed to fetch metadata of chaincode %s on channel %s", chaincodeID, channelID))
+		}
+		return ParseMetadata(payload)
+	})
+}
+
+//get returns the cached metadata of the key or fetches it, the gets of the key arriving during the fetch waiting for its outcome.
+//A failed fetch is not cached so that the next get fetches again.
+func (c *MetadataCache) get(key string, fetch func() (*ChaincodeMetadata, error)) (*ChaincodeMetadata, error) {
+	c.mutex.Lock()
+	if metadata := c.metadatas[key]; metadata != nil {
+		c.mutex.Unlock()
+		return metadata, nil
+	}
+	if inFlight := c.fetches[key]; inFlight != nil {
+		c.mutex.Unlock()
+		<-inFlight.done
+		return inFlight.metadata, inFlight.err
+	}
+	f := &metadataFetch{done: make(chan struct{})}
+	c.fetches[key] = f
+	c.mutex.Unlock()
+
+	f.metadata, f.err = fetch()
+	c.mutex.Lock()
+	//The metadata of a fetch started before the key was invalidated may be outdated and is not cached
+	if c.fetches[key] == f {
+		delete(c.fetches, key)
+		if f.err == nil {
+			c.metadatas[key] = f.metadata
+		}
+	}
+	c.mutex.Unlock()
+	close(f.done)
+	return f.metadata, f.err
+}
+
+//Invalidate removes the metadata of the chaincode from the cache, e.g. once the chaincode is upgraded
+func (c *MetadataCache) Invalidate(channelID string, chaincodeID string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.metadatas, metadataKey(channelID, chaincodeID))
+	delete(c.fetches, metadataKey(channelID, chaincodeID))
+}
+
+//validate checks the request against the metadata of its chaincode
+func (c *MetadataCache) validate(provider providers.FabricNetworkClientProvider, req InvokeRequest) error {
+	metadata, err := c.Get(provider, req.ChannelID, req.ChaincodeID)
+	if err != nil {
+		return err
+	}
+	return metadata.ValidateArgs(req.Fcn, req.Args)
+}
+
+//ArgumentsError is returned when a function or its arguments do not match the chaincode metadata
+type ArgumentsError struct {
+	Fcn      string
+	Problems []string
+}
+
+func (e *ArgumentsError) Error() string {
+	return fmt.Sprintf("invalid call of function %s: %s", e.Fcn, strings.Join(e.Problems, "; "))
+}
+
+//Transaction returns the contract and transaction metadata of the function.
+//The function is the transaction name, prefixed by its contract name unless it belongs to the default contract.
+func (metadata *ChaincodeMetadata) Transaction(fn string) (ContractMetadata, TransactionMetadata, error) {
+	contractName, txName := "", fn
+	if i := strings.LastIndex(fn, ":"); i >= 0 {
+		contractName, txName = fn[:i], fn[i+1:]
+	}
+	var names []string
+	for _, contract := range metadata.Contracts {
+		if contractName == "" && !contract.Default && len(metadata.Contracts) > 1 {
+			continue
+		}
+		if contractName != "" && contract.Name != contractName {
+			continue
+		}
+		for _, tx := range contract.Transactions {
+			if tx.Name == txName {
+				return contract, tx, nil
+			}
+		}
+	}
+	for _, contract := range metadata.Contracts {
+		for _, tx := range contract.Transactions {
+			names = append(names, tx.QualifiedName(contract.Name))
+		}
+	}
+	problem := "function is not defined by the chaincode"
+	if suggestion := closest(fn, names); suggestion != "" {
+		problem += ", did you mean " + suggestion + "?"
+	}
+	return ContractMetadata{}, TransactionMetadata{}, &ArgumentsError{Fcn: fn, Problems: []string{problem}}
+}
+
+//ValidateArgs checks that the function is defined by the chaincode and that the arguments match its parameters
+func (metadata *ChaincodeMetadata) ValidateArgs(fn string, args [][]byte) error {
+	_, tx, err := metadata.Transaction(fn)
+	if err != nil {
+		return err
+	}
+	if len(args) != len(tx.Parameters) {
+		return &ArgumentsError{Fcn: fn, Problems: []string{fmt.Sprintf("expected %d arguments, got %d", len(tx.Parameters), len(args))}}
+	}
+	var problems []string
+	for i, param := range tx.Parameters {
+		for _, problem := range metadata.validateArg(param.Schema, args[i]) {
+			problems = append(problems, fmt.Sprintf("argument %d (%s) %s", i, param.Name, problem))
+		}
+	}
+	if len(problems) > 0 {
+		return &ArgumentsError{Fcn: fn, Problems: problems}
+	}
+	return nil
+}
+
+//validateArg checks the raw argument against the schema. Strings, numbers and booleans are passed as text, other values as JSON.
+func (metadata *ChaincodeMetadata) validateArg(schema *Schema, arg []byte) []string {
+	schema, err := metadata.Resolve(schema)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	if schema == nil {
+		return nil
+	}
+	var value interface{}
+	switch schema.Type {
+	case "string":
+		value = string(arg)
+	case "integer", "number":
+		number, err := strconv.ParseFloat(string(arg), 64)
+		if err != nil {
+			return []string{fmt.Sprintf("is not a %s: %q", schema.Type, arg)}
+		}
+		value = number
+	case "boolean":
+		b, err := strconv.ParseBool(string(arg))
+		if err != nil {
+			return []string{fmt.Sprintf("is not a boolean: %q", arg)}
+		}
+		value = b
+	default:
+		if err := json.Unmarshal(arg, &value); err != nil {
+			return []string{"is not valid JSON: " + err.Error()}
+		}
+	}
+	return metadata.validateValue(schema, value, "")
+}
+
+//validateValue checks the decoded JSON value against the schema
+func (metadata *ChaincodeMetadata) validateValue(schema *Schema, value interface{}, path string) []string {
+	schema, err := metadata.Resolve(schema)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	if schema == nil {
+		return nil
+	}
+	at := ""
+	if path != "" {
+		at = " at " + path
+	}
+	var problems []string
+	switch schema.Type {
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return []string{"is not a string" + at}
+		}
+		if schema.Pattern != "" {
+			if re, err := regexp.Compile(schema.Pattern); err == nil && !re.MatchString(s) {
+				problems = append(problems, fmt.Sprintf("does not match pattern %s%s", schema.Pattern, at))
+			}
+		}
+	case "integer", "number":
+		n, ok := value.(float64)
+		if !ok {
+			return []string{"is not a " + schema.Type + at}
+		}
+		if schema.Type == "integer" && n != float64(int64(n)) {
+			problems = append(problems, "is not an integer"+at)
+		}
+		if schema.Minimum != nil && n < *schema.Minimum {
+			problems = append(problems, fmt.Sprintf("is less than %v%s", *schema.Minimum, at))
+		}
+		if schema.Maximum != nil && n > *schema.Maximum {
+			problems = append(problems, fmt.Sprintf("is greater than %v%s", *schema.Maximum, at))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return []string{"is not a boolean" + at}
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return []string{"is not an array" + at}
+		}
+		for i, item := range items {
+			problems = append(problems, metadata.validateValue(schema.Items, item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return []string{"is not an object" + at}
+		}
+		for _, property := range schema.Required {
+			if _, found := object[property]; !found {
+				problems = append(problems, "is missing required property "+joinPath(path, property))
+			}
+		}
+		var properties []string
+		for property := range object {
+			properties = append(properties, property)
+		}
+		sort.Strings(properties)
+		for _, property := range properties {
+			if propertySchema, found := schema.Properties[property]; found {
+				problems = append(problems, metadata.validateValue(propertySchema, object[property], joinPath(path, property))...)
+			}
+		}
+	}
+	if len(schema.Enum) > 0 && !inEnum(schema.Enum, value) {
+		problems = append(problems, fmt.Sprintf("is not one of %v%s", schema.Enum, at))
+	}
+	return problems
+}
+
+func joinPath(path string, property string) string {
+	if path == "" {
+		return property
+	}
+	return path + "." + property
+}
+
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, allowed := range enum {
+		if fmt.Sprint(allowed) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+//closest returns the name closest to the function, if it is close enough to be a typo
+func closest(fn string, names []string) string {
+	best, bestDistance := "", 3
+	for _, name := range names {
+		for _, candidate := range []string{name, name[strings.LastIndex(name, ":")+1:]} {
+			if d := editDistance(strings.ToLower(fn), strings.ToLower(candidate)); d < bestDistance {
+				best, bestDistance = name, d
+			}
+		}
+	}
+	return best
+}
+
+//editDistance returns the Levenshtein distance between the strings
+func editDistance(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
+
+func minInt(values ...int) int {
+	min := values[0]
+	for _, v := range values[1:] {
+		if v < min {
+			min = v
+		}
+	}
+	return min
+}
@@ -0,0 +1,189 @@
+package chaincode
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestMetadataCacheSharedFetch(t *testing.T) {
+	cache := NewMetadataCache()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var fetches int32
+	fetch := func() (*ChaincodeMetadata, error) {
+		if atomic.AddInt32(&fetches, 1) == 1 {
+			close(started)
+		}
+		<-release
+		return &ChaincodeMetadata{}, nil
+	}
+
+	const gets = 10
+	results := make([]*ChaincodeMetadata, gets)
+	var wg sync.WaitGroup
+	for i := 0; i < gets; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = cache.get("mychannel/mycc", fetch)
+		}(i)
+	}
+	<-started
+	close(release)
+	wg.Wait()
+
+	if fetches != 1 {
+		t.Errorf("%d fetches, expected 1", fetches)
+	}
+	for i, metadata := range results {
+		if metadata == nil || metadata != results[0] {
+			t.Errorf("get %d returned metadata %p, expected the shared metadata %p", i, metadata, results[0])
+		}
+	}
+}
+
+func TestMetadataCacheFetchNotCached(t *testing.T) {
+	tests := []struct {
+		name string
+		//during runs while the first fetch is in flight
+		during func(cache *MetadataCache)
+		err    error
+	}{
+		{name: "failed fetch", during: func(*MetadataCache) {}, err: errors.New("peer unreachable")},
+		{name: "invalidated during fetch", during: func(cache *MetadataCache) { cache.Invalidate("mychannel", "mycc") }},
+	}
+	for _, test := range tests {
+		cache := NewMetadataCache()
+		fetches := 0
+		fetch := func() (*ChaincodeMetadata, error) {
+			fetches++
+			if fetches == 1 {
+				test.during(cache)
+				return &ChaincodeMetadata{}, test.err
+			}
+			return &ChaincodeMetadata{}, nil
+		}
+		key := metadataKey("mychannel", "mycc")
+		if _, err := cache.get(key, fetch); err != test.err {
+			t.Errorf("%s: error %v, expected %v", test.name, err, test.err)
+		}
+		if _, err := cache.get(key, fetch); err != nil {
+			t.Errorf("%s: second get failed: %s", test.name, err)
+		}
+		if _, err := cache.get(key, fetch); err != nil {
+			t.Errorf("%s: third get failed: %s", test.name, err)
+		}
+		if fetches != 2 {
+			t.Errorf("%s: %d fetches, expected 2", test.name, fetches)
+		}
+	}
+}
+
+const testMetadata = `{
+	"contracts": {
+		"AssetContract": {
+			"name": "AssetContract",
+			"default": true,
+			"transactions": [
+				{"name": "CreateAsset", "tag": ["submit"], "parameters": [
+					{"name": "id", "schema": {"type": "string", "pattern": "^A"}},
+					{"name": "value", "schema": {"type": "integer", "minimum": 0}},
+					{"name": "asset", "schema": {"$ref": "#/components/schemas/Asset"}}
+				]},
+				{"name": "ReadAsset", "tag": ["evaluate"], "parameters": [{"name": "id", "schema": {"type": "string"}}]}
+			]
+		},
+		"OtherContract": {
+			"name": "OtherContract",
+			"transactions": [{"name": "Ping"}]
+		}
+	},
+	"components": {
+		"schemas": {
+			"Asset": {
+				"type": "object",
+				"required": ["ID"],
+				"properties": {
+					"ID": {"type": "string"},
+					"tags": {"type": "array", "items": {"type": "string"}},
+					"color": {"type": "string", "enum": ["red", "green"]}
+				}
+			}
+		}
+	}
+}`
+
+func TestValidateArgs(t *testing.T) {
+	metadata, err := ParseMetadata([]byte(testMetadata))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		fn      string
+		args    []string
+		problem string
+	}{
+		{name: "valid", fn: "CreateAsset", args: []string{"A1", "10", `{"ID":"A1","tags":["x"],"color":"red"}`}},
+		{name: "qualified function of the default contract", fn: "AssetContract:ReadAsset", args: []string{"A1"}},
+		{name: "qualified function of another contract", fn: "OtherContract:Ping"},
+		{name: "unqualified function of another contract", fn: "Ping", problem: "function is not defined by the chaincode, did you mean OtherContract:Ping?"},
+		{name: "misspelled function", fn: "CreatAsset", args: []string{"A1", "10", `{"ID":"A1"}`}, problem: "function is not defined by the chaincode, did you mean AssetContract:CreateAsset?"},
+		{name: "unknown function", fn: "DeleteEverything", problem: "function is not defined by the chaincode"},
+		{name: "missing argument", fn: "ReadAsset", problem: "expected 1 arguments, got 0"},
+		{name: "pattern mismatch", fn: "CreateAsset", args: []string{"B1", "10", `{"ID":"B1"}`}, problem: "argument 0 (id) does not match pattern ^A"},
+		{name: "not a number", fn: "CreateAsset", args: []string{"A1", "ten", `{"ID":"A1"}`}, problem: `argument 1 (value) is not a integer: "ten"`},
+		{name: "not an integer", fn: "CreateAsset", args: []string{"A1", "1.5", `{"ID":"A1"}`}, problem: "argument 1 (value) is not an integer"},
+		{name: "below minimum", fn: "CreateAsset", args: []string{"A1", "-1", `{"ID":"A1"}`}, problem: "argument 1 (value) is less than 0"},
+		{name: "invalid JSON", fn: "CreateAsset", args: []string{"A1", "1", `{"ID":`}, problem: "argument 2 (asset) is not valid JSON"},
+		{name: "missing required property", fn: "CreateAsset", args: []string{"A1", "1", `{"color":"red"}`}, problem: "argument 2 (asset) is missing required property ID"},
+		{name: "nested type mismatch", fn: "CreateAsset", args: []string{"A1", "1", `{"ID":"A1","tags":["x",1]}`}, problem: "argument 2 (asset) is not a string at tags[1]"},
+		{name: "not in enum", fn: "CreateAsset", args: []string{"A1", "1", `{"ID":"A1","color":"blue"}`}, problem: "argument 2 (asset) is not one of [red green] at color"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var args [][]byte
+			for _, arg := range test.args {
+				args = append(args, []byte(arg))
+			}
+			err := metadata.ValidateArgs(test.fn, args)
+			if test.problem == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			argsErr, ok := err.(*ArgumentsError)
+			if !ok {
+				t.Fatalf("expected an *ArgumentsError, got %T: %v", err, err)
+			}
+			if len(argsErr.Problems) != 1 || !strings.HasPrefix(argsErr.Problems[0], test.problem) {
+				t.Errorf("expected the problem %q, got %q", test.problem, argsErr.Problems)
+			}
+		})
+	}
+}
+
+func TestClosest(t *testing.T) {
+	names := []string{"AssetContract:CreateAsset", "AssetContract:ReadAsset", "OtherContract:Ping"}
+	tests := []struct {
+		fn   string
+		want string
+	}{
+		{fn: "CreatAsset", want: "AssetContract:CreateAsset"},
+		{fn: "createasset", want: "AssetContract:CreateAsset"},
+		{fn: "AssetContract:ReadAset", want: "AssetContract:ReadAsset"},
+		{fn: "Pong", want: "OtherContract:Ping"},
+		{fn: "Xyzw", want: ""},
+		{fn: "DeleteAsset", want: ""},
+	}
+	for _, test := range tests {
+		if got := closest(test.fn, names); got != test.want {
+			t.Errorf("closest(%s) = %q, want %q", test.fn, got, test.want)
+		}
+	}
+}
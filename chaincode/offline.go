@@ -0,0 +1,511 @@
+package chaincode
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"dendrix.io/fabricsdk/providers"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	fabcontext "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	contextImpl "github.com/hyperledger/fabric-sdk-go/pkg/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/txn"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"github.com/pkg/errors"
+)
+
+//defaultOfflineTimeout is the timeout of the endorsement and ordering requests of the offline client unless a timeout is set in the options
+const defaultOfflineTimeout = 30 * time.Second
+
+//offlineCommitPollInterval is the interval between the commit status queries of a transaction submitted by the offline client
+const offlineCommitPollInterval = time.Second
+
+//qscc is the system chaincode the commit status of a transaction is queried from
+const (
+	qscc                   = "qscc"
+	qsccGetTransactionByID = "GetTransactionByID"
+)
+
+//UnsignedProposal is a transaction proposal to sign with the key of the identity it was created for
+type UnsignedProposal struct {
+	TxID      string
+	ChannelID string
+	//Bytes is the marshalled proposal, the message to sign
+	Bytes    []byte
+	identity SignerIdentity
+	proposal *fab.TransactionProposal
+}
+
+//Sign signs the proposal with the signer and checks the signature against the identity of the proposal
+func (p *UnsignedProposal) Sign(signer Signer) (*SignedProposal, error) {
+	signature, err := signer.Sign(p.Bytes)
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("failed to sign proposal of transaction %s", p.TxID))
+	}
+	if err := verifySignature(p.identity.Certificate, p.Bytes, signature); err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("invalid signature of proposal of transaction %s", p.TxID))
+	}
+	return p.WithSignature(signature), nil
+}
+
+//WithSignature returns the proposal signed with a signature computed by the caller
+func (p *UnsignedProposal) WithSignature(signature []byte) *SignedProposal {
+	return &SignedProposal{
+		TxID:      p.TxID,
+		ChannelID: p.ChannelID,
+		signed:    &pb.SignedProposal{ProposalBytes: p.Bytes, Signature: signature},
+		identity:  p.identity,
+		proposal:  p.proposal,
+	}
+}
+
+//SignedProposal is a signed transaction proposal, ready to be sent for endorsement
+type SignedProposal struct {
+	TxID      string
+	ChannelID string
+	signed    *pb.SignedProposal
+	identity  SignerIdentity
+	proposal  *fab.TransactionProposal
+}
+
+//EndorsedProposal holds the endorsements of a proposal
+type EndorsedProposal struct {
+	TxID      string
+	ChannelID string
+	Payload   []byte
+	//ChaincodeStatus is the status returned by the chaincode
+	ChaincodeStatus int32
+	Endorsers       []string
+	//Failures are the target peers that could not be reached or whose chaincode rejected the proposal
+	Failures  []EndorsementFailure
+	identity  SignerIdentity
+	proposal  *fab.TransactionProposal
+	responses []*fab.TransactionProposalResponse
+}
+
+//UnsignedEnvelope is an endorsed transaction to sign with the key of the proposal creator
+type UnsignedEnvelope struct {
+	TxID      string
+	ChannelID string
+	//Bytes is the marshalled payload of the envelope, the message to sign
+	Bytes    []byte
+	endorsed *EndorsedProposal
+}
+
+//Sign signs the envelope with the signer and checks the signature against the identity of the proposal
+func (e *UnsignedEnvelope) Sign(signer Signer) (*SignedEnvelope, error) {
+	signature, err := signer.Sign(e.Bytes)
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("failed to sign transaction %s", e.TxID))
+	}
+	if err := verifySignature(e.endorsed.identity.Certificate, e.Bytes, signature); err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("invalid signature of transaction %s", e.TxID))
+	}
+	return e.WithSignature(signature), nil
+}
+
+//WithSignature returns the envelope signed with a signature computed by the caller
+func (e *UnsignedEnvelope) WithSignature(signature []byte) *SignedEnvelope {
+	return &SignedEnvelope{
+		TxID:      e.TxID,
+		ChannelID: e.ChannelID,
+		envelope:  &fab.SignedEnvelope{Payload: e.Bytes, Signature: signature},
+		endorsed:  e.endorsed,
+	}
+}
+
+//SignedEnvelope is a signed transaction, ready to be submitted to the orderer
+type SignedEnvelope struct {
+	TxID      string
+	ChannelID string
+	envelope  *fab.SignedEnvelope
+	endorsed  *EndorsedProposal
+}
+
+//OfflineClient executes chaincode functions in steps, the proposal and transaction being signed by an external signer.
+//The client provider only supplies the endpoints, TLS config and orderers of the channel: every proposal and envelope,
+//including the queries of the commit status of the transactions, is signed by the external signer.
+//The channel context is created for the identity of the signer of the client, so that the client org needs neither user nor keystore.
+type OfflineClient struct {
+	provider  providers.FabricNetworkClientProvider
+	channelID string
+	options   Options
+	chCtx     fabcontext.Channel
+}
+
+//NewOfflineClient returns an OfflineClient on the channel whose channel context is created for the identity of the signer.
+//Closing it closes the client provider.
+func NewOfflineClient(provider providers.FabricNetworkClientProvider, channelID string, signer Signer, opts ...Option) (*OfflineClient, error) {
+	if provider == nil {
+		return nil, errors.Errorf("Fabric network client provider is not set.")
+	}
+	if channelID == "" {
+		return nil, errors.Errorf("invalid offline client, missing required field(s): ChannelID")
+	}
+	if signer == nil {
+		return nil, errors.New("signer is not set")
+	}
+	options, err := NewOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := options.Validate(OfflineRequestType); err != nil {
+		return nil, err
+	}
+	identity, err := newSignerSigningIdentity(signer)
+	if err != nil {
+		return nil, err
+	}
+	channelProvider, err := provider.ChannelContextWithIdentity(channelID, identity)
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("failed to get context of channel %s", channelID))
+	}
+	chCtx, err := channelProvider()
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("failed to get context of channel %s", channelID))
+	}
+	return &OfflineClient{provider: provider, channelID: channelID, options: options, chCtx: chCtx}, nil
+}
+
+//CreateProposal returns the unsigned proposal of the chaincode function, created by the signer identity.
+//The proposal has the nonce set in the options if any, so that its transaction ID is known beforehand.
+func (c *OfflineClient) CreateProposal(identity SignerIdentity, req InvokeRequest) (*UnsignedProposal, error) {
+	req.ChannelID = c.channelID
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if c.options.MetadataCache != nil {
+		if err := c.options.MetadataCache.validate(c.provider, req); err != nil {
+			return nil, err
+		}
+	}
+	creator, err := SerializeIdentity(identity)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCreator(c.options.Creator, creator); err != nil {
+		return nil, err
+	}
+	return c.createProposal(identity, c.options.Nonce, fab.ChaincodeInvokeRequest{
+		ChaincodeID: req.ChaincodeID,
+		Fcn:         req.Fcn,
+		Args:        req.Args,
+	})
+}
+
+//createProposal returns the unsigned proposal of the chaincode request created by the signer identity, with a random nonce unless one is set
+func (c *OfflineClient) createProposal(identity SignerIdentity, nonce []byte, request fab.ChaincodeInvokeRequest) (*UnsignedProposal, error) {
+	creator, err := SerializeIdentity(identity)
+	if err != nil {
+		return nil, err
+	}
+	txh, err := newTransactionHeader(c.channelID, nonce, creator)
+	if err != nil {
+		return nil, err
+	}
+	proposal, err := txn.CreateChaincodeInvokeProposal(txh, request)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create proposal")
+	}
+	proposalBytes, err := proto.Marshal(proposal.Proposal)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal proposal")
+	}
+	return &UnsignedProposal{
+		TxID:      string(proposal.TxnID),
+		ChannelID: c.channelID,
+		Bytes:     proposalBytes,
+		identity:  identity,
+		proposal:  proposal,
+	}, nil
+}
+
+//Endorse sends the signed proposal to the target peers, or to all the client org peers, and checks that they returned matching responses.
+//The endorsers that could not be reached or rejected the proposal are listed in the failures of the endorsed proposal.
+//If the endorsers did not all return the same successful response, the endorsed proposal is returned with an EndorsementMismatchError
+//so that the caller can still submit the endorsements if they satisfy the endorsement policy.
+func (c *OfflineClient) Endorse(signed *SignedProposal) (*EndorsedProposal, error) {
+	targets := c.options.Targets
+	if len(targets) == 0 {
+		targets = c.provider.ClientOrgPeers()
+	}
+	if len(targets) == 0 {
+		return nil, errNoPeersFound(c.provider.ClientOrgID())
+	}
+	reqCtx, cancel := c.requestContext()
+	defer cancel()
+
+	responses := make([]*fab.TransactionProposalResponse, len(targets))
+	errs := make([]error, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target fab.Peer) {
+			defer wg.Done()
+			response, err := target.ProcessTransactionProposal(reqCtx, fab.ProcessProposalRequest{SignedProposal: signed.signed})
+			if err == nil && response.Status != int32(common.Status_SUCCESS) {
+				err = status.New(status.EndorserServerStatus, response.Status, response.GetResponse().GetMessage(), nil)
+			}
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			responses[i] = response
+		}(i, target)
+	}
+	wg.Wait()
+
+	endorsed := &EndorsedProposal{
+		TxID:      signed.TxID,
+		ChannelID: signed.ChannelID,
+		identity:  signed.identity,
+		proposal:  signed.proposal,
+	}
+	for i, target := range targets {
+		if errs[i] != nil {
+			endorsed.Failures = append(endorsed.Failures, newEndorsementFailure(target.URL(), errs[i]))
+			continue
+		}
+		endorsed.responses = append(endorsed.responses, responses[i])
+		endorsed.Endorsers = append(endorsed.Endorsers, responses[i].Endorser)
+	}
+	if len(endorsed.responses) == 0 {
+		failure := endorsed.Failures[0]
+		return nil, errors.WithMessage(failure.err, fmt.Sprintf("endorsement of transaction %s failed on peer %s", signed.TxID, failure.Endorser))
+	}
+	endorsed.Payload = endorsed.responses[0].Response.Payload
+	endorsed.ChaincodeStatus = endorsed.responses[0].ChaincodeStatus
+
+	report, err := newEndorsementReport(endorsed.responses, endorsed.Failures)
+	if err != nil {
+		return nil, err
+	}
+	if !report.Consistent() {
+		return endorsed, &EndorsementMismatchError{Report: report}
+	}
+	return endorsed, nil
+}
+
+//CreateEnvelope returns the unsigned transaction of the endorsed proposal
+func (c *OfflineClient) CreateEnvelope(endorsed *EndorsedProposal) (*UnsignedEnvelope, error) {
+	tx, err := txn.New(fab.TransactionRequest{Proposal: endorsed.proposal, ProposalResponses: endorsed.responses})
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create transaction")
+	}
+	header := &common.Header{}
+	if err := proto.Unmarshal(tx.Proposal.Header, header); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal proposal header")
+	}
+	txBytes, err := proto.Marshal(tx.Transaction)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal transaction")
+	}
+	payloadBytes, err := proto.Marshal(&common.Payload{Header: header, Data: txBytes})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal transaction payload")
+	}
+	return &UnsignedEnvelope{
+		TxID:      endorsed.TxID,
+		ChannelID: endorsed.ChannelID,
+		Bytes:     payloadBytes,
+		endorsed:  endorsed,
+	}, nil
+}
+
+//Submit sends the signed transaction to the orderer and returns without waiting for its commit.
+//The commit status of the transaction is then queried from a client org peer, or the first target, with queries signed by the signer.
+func (c *OfflineClient) Submit(envelope *SignedEnvelope, signer Signer) (*Commit, error) {
+	if signer == nil {
+		return nil, errors.New("signer is not set")
+	}
+	orderers, err := c.orderers()
+	if err != nil {
+		return nil, err
+	}
+	reqCtx, cancel := c.requestContext()
+	defer cancel()
+	if _, err := txn.BroadcastEnvelope(reqCtx, envelope.envelope, orderers); err != nil {
+		return nil, errors.WithMessage(err, "SendTransaction failed")
+	}
+
+	commit := newCommit()
+	commit.txID = envelope.TxID
+	response := channel.Response{
+		TransactionID:   fab.TransactionID(envelope.TxID),
+		Payload:         envelope.endorsed.Payload,
+		ChaincodeStatus: envelope.endorsed.ChaincodeStatus,
+		Proposal:        envelope.endorsed.proposal,
+		Responses:       envelope.endorsed.responses,
+	}
+	go c.waitForCommit(commit, signer, envelope.endorsed.identity, response)
+	return commit, nil
+}
+
+//waitForCommit queries the commit status of the transaction until it is committed or the commit timeout expires.
+//Unlike the commit events, which are delivered to the identity of the client provider, the queries are signed by the signer.
+func (c *OfflineClient) waitForCommit(commit *Commit, signer Signer, identity SignerIdentity, response channel.Response) {
+	timeout := time.After(c.commitTimeout())
+	for {
+		code, committed, err := c.queryValidationCode(signer, identity, commit.txID)
+		if err != nil {
+			commit.complete(nil, err)
+			return
+		}
+		if committed {
+			response.TxValidationCode = code
+			result := newResult(response)
+			if code != pb.TxValidationCode_VALID {
+				commit.complete(result, status.New(status.EventServerStatus, int32(code), "received invalid transaction", nil))
+				return
+			}
+			commit.complete(result, nil)
+			return
+		}
+		select {
+		case <-time.After(offlineCommitPollInterval):
+		case <-timeout:
+			commit.complete(nil, status.New(status.ClientStatus, status.Timeout.ToInt32(), fmt.Sprintf("transaction %s was not committed", commit.txID), nil))
+			return
+		}
+	}
+}
+
+//queryValidationCode queries the validation code of the transaction with qscc, committed is false if the transaction is not found yet.
+//Any other failure, to sign the query, reach the peer or run qscc, is returned.
+func (c *OfflineClient) queryValidationCode(signer Signer, identity SignerIdentity, txID string) (pb.TxValidationCode, bool, error) {
+	proposal, err := c.createProposal(identity, nil, fab.ChaincodeInvokeRequest{
+		ChaincodeID: qscc,
+		Fcn:         qsccGetTransactionByID,
+		Args:        [][]byte{[]byte(c.channelID), []byte(txID)},
+	})
+	if err != nil {
+		return 0, false, err
+	}
+	signature, err := signer.Sign(proposal.Bytes)
+	if err != nil {
+		return 0, false, errors.WithMessage(err, fmt.Sprintf("failed to sign commit status query of transaction %s", txID))
+	}
+	var target fab.Peer
+	if len(c.options.Targets) > 0 {
+		target = c.options.Targets[0]
+	} else if target, err = randomPeer(c.provider.ClientOrgID(), c.provider.ClientOrgPeers()); err != nil {
+		return 0, false, err
+	}
+	reqCtx, cancel := c.requestContext()
+	defer cancel()
+	response, err := target.ProcessTransactionProposal(reqCtx, fab.ProcessProposalRequest{SignedProposal: proposal.WithSignature(signature).signed})
+	if err == nil && response.Status != int32(common.Status_SUCCESS) {
+		err = status.New(status.ChaincodeStatus, response.Status, response.GetResponse().GetMessage(), nil)
+	}
+	if err != nil {
+		if isTransactionNotFound(err) {
+			//The transaction is not found until it is committed
+			return 0, false, nil
+		}
+		return 0, false, errors.WithMessage(err, fmt.Sprintf("failed to query commit status of transaction %s on peer %s", txID, target.URL()))
+	}
+	processed := &pb.ProcessedTransaction{}
+	if err := proto.Unmarshal(response.Response.Payload, processed); err != nil {
+		return 0, false, errors.Wrap(err, "failed to unmarshal processed transaction")
+	}
+	return pb.TxValidationCode(processed.ValidationCode), true, nil
+}
+
+//isTransactionNotFound reports whether qscc did not find the transaction, the only failure of a commit status query worth retrying
+func isTransactionNotFound(err error) bool {
+	s, ok := status.FromError(err)
+	if !ok || (s.Group != status.ChaincodeStatus && s.Group != status.EndorserServerStatus) {
+		return false
+	}
+	return strings.Contains(s.Message, "no such transaction ID")
+}
+
+//Execute runs all the steps of the chaincode function, signing with the signer, and returns its result once committed
+func (c *OfflineClient) Execute(signer Signer, req InvokeRequest) (*Result, error) {
+	identity, err := signer.Identity()
+	if err != nil {
+		return nil, err
+	}
+	proposal, err := c.CreateProposal(identity, req)
+	if err != nil {
+		return nil, err
+	}
+	signedProposal, err := proposal.Sign(signer)
+	if err != nil {
+		return nil, err
+	}
+	endorsed, err := c.Endorse(signedProposal)
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("failed to invoke function %s on chaincode %s", req.Fcn, req.ChaincodeID))
+	}
+	envelope, err := c.CreateEnvelope(endorsed)
+	if err != nil {
+		return nil, err
+	}
+	signedEnvelope, err := envelope.Sign(signer)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := c.Submit(signedEnvelope, signer)
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("failed to invoke function %s on chaincode %s", req.Fcn, req.ChaincodeID))
+	}
+	ctx := c.options.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	result, err := commit.Status(ctx)
+	if err != nil {
+		return result, errors.WithMessage(err, fmt.Sprintf("failed to invoke function %s on chaincode %s", req.Fcn, req.ChaincodeID))
+	}
+	result.Attempts = 1
+	return result, nil
+}
+
+//Close closes the client provider of the client
+func (c *OfflineClient) Close() {
+	c.provider.CloseSDK()
+}
+
+//requestContext returns the context of the endorsement, ordering and commit status requests, cancelled once the options context is done
+func (c *OfflineClient) requestContext() (context.Context, context.CancelFunc) {
+	timeout := c.options.Timeout
+	if timeout <= 0 {
+		timeout = defaultOfflineTimeout
+	}
+	reqOpts := []contextImpl.ReqContextOptions{contextImpl.WithTimeout(timeout)}
+	if c.options.Context != nil {
+		reqOpts = append(reqOpts, contextImpl.WithParent(c.options.Context))
+	}
+	return contextImpl.NewRequest(c.chCtx, reqOpts...)
+}
+
+//orderers returns the orderers of the channel
+func (c *OfflineClient) orderers() ([]fab.Orderer, error) {
+	ordererCfgs := c.chCtx.EndpointConfig().ChannelOrderers(c.channelID)
+	if len(ordererCfgs) == 0 {
+		return nil, errors.Errorf("no orderer configured for channel %s", c.channelID)
+	}
+	var orderers []fab.Orderer
+	for i := range ordererCfgs {
+		orderer, err := c.chCtx.InfraProvider().CreateOrdererFromConfig(&ordererCfgs[i])
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to create orderer")
+		}
+		orderers = append(orderers, orderer)
+	}
+	return orderers, nil
+}
+
+func (c *OfflineClient) commitTimeout() time.Duration {
+	if c.options.CommitWait != nil && c.options.CommitWait.Timeout > 0 {
+		return c.options.CommitWait.Timeout
+	}
+	return defaultCommitTimeout
+}
@@ -0,0 +1,29 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/pkg/errors"
+)
+
+func TestIsTransactionNotFound(t *testing.T) {
+	notFound := "Failed to get transaction with id abc, error no such transaction ID [abc] in index"
+	tests := []struct {
+		name     string
+		err      error
+		notFound bool
+	}{
+		{name: "not found", err: status.New(status.ChaincodeStatus, 500, notFound, nil), notFound: true},
+		{name: "not found by older peers", err: status.New(status.EndorserServerStatus, 500, notFound, nil), notFound: true},
+		{name: "wrapped not found", err: errors.WithMessage(status.New(status.ChaincodeStatus, 500, notFound, nil), "query failed"), notFound: true},
+		{name: "access denied", err: status.New(status.ChaincodeStatus, 500, "access denied for [GetTransactionByID][mychannel]", nil)},
+		{name: "connection failed", err: status.New(status.EndorserClientStatus, status.ConnectionFailed.ToInt32(), notFound, nil)},
+		{name: "plain error", err: errors.New(notFound)},
+	}
+	for _, test := range tests {
+		if notFound := isTransactionNotFound(test.err); notFound != test.notFound {
+			t.Errorf("%s: not found %t, expected %t", test.name, notFound, test.notFound)
+		}
+	}
+}
@@ -1,8 +1,15 @@
 package chaincode
 
 import (
+	"context"
+	"fmt"
+	"time"
+
 	"dendrix.io/fabricsdk/providers"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
 	"github.com/pkg/errors"
 )
 
@@ -17,6 +24,9 @@ import (
 	return string(response.Payload), nil
 } */
 
+//ErrQueryWrites is returned when a queried function writes to the ledger. Such functions must be executed instead.
+var ErrQueryWrites = errors.New("queried function writes to the ledger, use the execute client to commit the writes")
+
 type queryChaincodeClient struct {
 	//To indicate that this interface is implemented
 	ChaincodeClient
@@ -25,23 +35,78 @@ type queryChaincodeClient struct {
 	chaincodeID string
 	args        [][]byte
 	function    string
+	targets     []fab.Peer
+	timeout     time.Duration
+	ctx         context.Context
+	metadata    *MetadataCache
+	quorum      int
 }
 
 //NewQueryClient returns a ChaincodeClient implmentation for querying chaincode business functions
 func NewQueryClient(provider providers.FabricNetworkClientProvider, channelID string, chaincodeID string, fn string, args [][]byte) ChaincodeClient {
+	req := InvokeRequest{
+		ChannelID:   channelID,
+		ChaincodeID: chaincodeID,
+		Fcn:         fn,
+		Args:        args,
+	}
+	return newQueryClient(provider, req, Options{})
+}
+
+//NewQueryClientFromRequest returns a ChaincodeClient implmentation for querying the requested chaincode function
+func NewQueryClientFromRequest(provider providers.FabricNetworkClientProvider, req InvokeRequest, opts ...Option) (ChaincodeClient, error) {
+	if provider == nil {
+		return nil, errors.Errorf("Fabric network client provider is not set.")
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	options, err := NewOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := options.Validate(QueryRequestType); err != nil {
+		return nil, err
+	}
+	return newQueryClient(provider, req, options), nil
+}
+
+func newQueryClient(provider providers.FabricNetworkClientProvider, req InvokeRequest, options Options) *queryChaincodeClient {
 	i := new(queryChaincodeClient)
 	i.FabricNetworkClientProvider = provider
-	i.channelID = channelID
-	i.chaincodeID = chaincodeID
-	i.args = args
-	i.function = fn
+	i.channelID = req.ChannelID
+	i.chaincodeID = req.ChaincodeID
+	i.args = req.Args
+	i.function = req.Fcn
+	i.targets = options.Targets
+	i.timeout = options.Timeout
+	i.ctx = options.Context
+	i.metadata = options.MetadataCache
+	i.quorum = options.ConsensusQuorum
 	return i
 }
 
 func (ic queryChaincodeClient) Invoke() ([]byte, error) {
+	result, err := ic.InvokeResult()
+	if err != nil {
+		return nil, err
+	}
+	return result.Payload, nil
+}
+
+//InvokeResult evaluates the chaincode function on the endorsing peers only, the proposal is never sent to the orderer.
+//It returns an error wrapping ErrQueryWrites if the function writes to the ledger, since such writes would be discarded.
+//If metadata validation is set in the options, the request is checked against the chaincode metadata first.
+func (ic queryChaincodeClient) InvokeResult() (*Result, error) {
+	if ic.metadata != nil {
+		req := InvokeRequest{ChannelID: ic.channelID, ChaincodeID: ic.chaincodeID, Fcn: ic.function, Args: ic.args}
+		if err := ic.metadata.validate(ic.FabricNetworkClientProvider, req); err != nil {
+			return nil, err
+		}
+	}
 	chClient, err := ic.ChannelClient(ic.channelID)
 	if err != nil {
-		return []byte("0x00"), err
+		return nil, err
 	}
 
 	req := channel.Request{
@@ -49,13 +114,50 @@ func (ic queryChaincodeClient) Invoke() ([]byte, error) {
 		Fcn:         ic.function,
 		Args:        ic.args,
 	}
-	//target := ic.peer(ic.ClientOrgPeers())
-	response, err := chClient.Execute(req)
+	var response channel.Response
+	var consensus *consensusHandler
+	if ic.quorum > 0 {
+		targets := ic.targets
+		if len(targets) == 0 {
+			targets = ic.ClientOrgPeers()
+		}
+		if len(targets) < ic.quorum {
+			return nil, errors.Errorf("consensus read of function %s on chaincode %s requires %d peers, only %d available", req.Fcn, req.ChaincodeID, ic.quorum, len(targets))
+		}
+		consensus = &consensusHandler{quorum: ic.quorum}
+		//Each target is endorsed separately so that the quorum can be reached despite unreachable or dissenting peers
+		handler := invoke.NewProposalProcessorHandler(newEndorsementHandler(nil, &consensus.failures, consensus))
+		response, err = chClient.InvokeHandler(handler, req, channelRequestOptions(targets, ic.timeout, fab.Query, ic.ctx)...)
+	} else {
+		targets := ic.targets
+		if len(targets) == 0 && len(ic.ClientOrgPeers()) > 0 {
+			peer, err := randomPeer(ic.ClientOrgID(), ic.ClientOrgPeers())
+			if err != nil {
+				return nil, err
+			}
+			targets = []fab.Peer{peer}
+		}
+		response, err = chClient.Query(req, channelRequestOptions(targets, ic.timeout, fab.Query, ic.ctx)...)
+	}
 
 	if err != nil {
-		return nil, errors.Errorf("failed to query function %s on chaincode %s. Error - %s", req.Fcn, req.ChaincodeID, err.Error())
+		return nil, errors.WithMessage(err, fmt.Sprintf("failed to query function %s on chaincode %s", req.Fcn, req.ChaincodeID))
+	}
+	for _, r := range response.Responses {
+		writes, err := hasWrites(r)
+		if err != nil {
+			return nil, errors.Errorf("failed to inspect query response of function %s on chaincode %s from peer %s. Error - %s", req.Fcn, req.ChaincodeID, r.Endorser, err.Error())
+		}
+		if writes {
+			return nil, errors.Wrapf(ErrQueryWrites, "function %s on chaincode %s", req.Fcn, req.ChaincodeID)
+		}
+	}
+	result := newResult(response)
+	result.ValidationCode = pb.TxValidationCode_NOT_VALIDATED
+	if consensus != nil {
+		result.Endorsement = consensus.report
 	}
-	return response.Payload, nil
+	return result, nil
 }
 
 func (ic queryChaincodeClient) Terminate() {
@@ -0,0 +1,329 @@
+package chaincode
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	"github.com/pkg/errors"
+)
+
+//InstallRequest defines the chaincode to install on the network peers
+type InstallRequest struct {
+	ChaincodeID      string
+	ChaincodeVersion string
+	ChaincodePath    string
+}
+
+//Validate checks that the required fields of the request are set
+func (req InstallRequest) Validate() error {
+	return requireFields(map[string]string{
+		"ChaincodeID":      req.ChaincodeID,
+		"ChaincodeVersion": req.ChaincodeVersion,
+		"ChaincodePath":    req.ChaincodePath,
+	})
+}
+
+//DeployRequest defines the chaincode to instantiate or upgrade on a channel
+type DeployRequest struct {
+	ChannelID        string
+	ChaincodeID      string
+	ChaincodeVersion string
+	ChaincodePath    string
+	Args             [][]byte
+}
+
+//Validate checks that the required fields of the request are set
+func (req DeployRequest) Validate() error {
+	return requireFields(map[string]string{
+		"ChannelID":        req.ChannelID,
+		"ChaincodeID":      req.ChaincodeID,
+		"ChaincodeVersion": req.ChaincodeVersion,
+		"ChaincodePath":    req.ChaincodePath,
+	})
+}
+
+//InvokeRequest defines the chaincode function to execute or query on a channel
+type InvokeRequest struct {
+	ChannelID   string
+	ChaincodeID string
+	Fcn         string
+	Args        [][]byte
+}
+
+//Validate checks that the required fields of the request are set
+func (req InvokeRequest) Validate() error {
+	return requireFields(map[string]string{
+		"ChannelID":   req.ChannelID,
+		"ChaincodeID": req.ChaincodeID,
+		"Fcn":         req.Fcn,
+	})
+}
+
+func requireFields(fields map[string]string) error {
+	var missing []string
+	for _, name := range []string{"ChannelID", "ChaincodeID", "ChaincodeVersion", "ChaincodePath", "Fcn"} {
+		if value, found := fields[name]; found && value == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return errors.Errorf("invalid request, missing required field(s): %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+//Options holds the optional settings of a chaincode request.
+//Policy and collection config only apply to instantiate and upgrade requests.
+type Options struct {
+	Policy               string
+	CollectionConfigFile string
+	CollectionConfig     []*common.CollectionConfig
+	Targets              []fab.Peer
+	Timeout              time.Duration
+	//Identity is the name of a registered identity to sign the request with instead of the configured user and admin
+	Identity string
+	//CommitWait sets the peers an executed transaction must be committed on, only applies to execute requests
+	CommitWait *CommitWait
+	//ConsensusQuorum is the number of endorsers that must return the same response to a query, 0 to query a single peer
+	ConsensusQuorum int
+	//MVCCRetry resubmits executed transactions invalidated by an MVCC read conflict, only applies to execute requests
+	MVCCRetry *MVCCRetry
+	//Context cancels the execute and query requests when done
+	Context context.Context
+	//Codec marshals the values passed to a contract and unmarshals its payloads
+	Codec Codec
+	//MetadataCache holds the contract metadata execute and query requests are checked against
+	MetadataCache *MetadataCache
+	//Nonce is the nonce of an executed transaction, which sets its ID with the creator, see ComputeTransactionID
+	Nonce []byte
+	//Creator is the serialized identity signing an executed transaction, checked against the identity of the request
+	Creator []byte
+}
+
+//Option sets an optional setting of a chaincode request
+type Option func(opts *Options) error
+
+//NewOptions applies the options in order
+func NewOptions(opts ...Option) (Options, error) {
+	var options Options
+	for _, opt := range opts {
+		if err := opt(&options); err != nil {
+			return options, err
+		}
+	}
+	return options, nil
+}
+
+//RequestType is the type of a chaincode request, which determines the options that apply to it
+type RequestType int
+
+//List of request types
+const (
+	InstallRequestType RequestType = iota
+	InstantiateRequestType
+	UpgradeRequestType
+	ExecuteRequestType
+	ExecuteAsyncRequestType
+	SimulateRequestType
+	QueryRequestType
+	OfflineRequestType
+)
+
+func (t RequestType) String() string {
+	switch t {
+	case InstallRequestType:
+		return "install"
+	case InstantiateRequestType:
+		return "instantiate"
+	case UpgradeRequestType:
+		return "upgrade"
+	case ExecuteRequestType:
+		return "execute"
+	case ExecuteAsyncRequestType:
+		return "asynchronous execute"
+	case SimulateRequestType:
+		return "simulate"
+	case QueryRequestType:
+		return "query"
+	case OfflineRequestType:
+		return "offline"
+	}
+	return fmt.Sprintf("RequestType(%d)", int(t))
+}
+
+//Validate rejects the options set that do not apply to the request type, rather than ignoring them.
+//The codec only applies to contracts, whose options are shared by their submits and evaluates and are not validated.
+func (opts Options) Validate(reqType RequestType) error {
+	var invalid []string
+	check := func(name string, set bool, types ...RequestType) {
+		if !set {
+			return
+		}
+		for _, t := range types {
+			if t == reqType {
+				return
+			}
+		}
+		invalid = append(invalid, name)
+	}
+	transactions := []RequestType{ExecuteRequestType, ExecuteAsyncRequestType, SimulateRequestType, OfflineRequestType}
+	check("Policy", opts.Policy != "", InstantiateRequestType, UpgradeRequestType)
+	check("CollectionConfigFile", opts.CollectionConfigFile != "", InstantiateRequestType, UpgradeRequestType)
+	check("CollectionConfig", opts.CollectionConfig != nil, InstantiateRequestType, UpgradeRequestType)
+	check("Identity", opts.Identity != "", InstallRequestType, InstantiateRequestType, UpgradeRequestType, ExecuteRequestType, ExecuteAsyncRequestType, SimulateRequestType, QueryRequestType)
+	check("CommitWait", opts.CommitWait != nil, ExecuteRequestType, OfflineRequestType)
+	check("ConsensusQuorum", opts.ConsensusQuorum > 0, QueryRequestType)
+	check("MVCCRetry", opts.MVCCRetry != nil, ExecuteRequestType)
+	check("Context", opts.Context != nil, append(transactions, QueryRequestType)...)
+	check("Codec", opts.Codec != nil)
+	check("MetadataCache", opts.MetadataCache != nil, append(transactions, QueryRequestType, UpgradeRequestType)...)
+	check("Nonce", len(opts.Nonce) > 0, transactions...)
+	check("Creator", len(opts.Creator) > 0, transactions...)
+	if len(invalid) > 0 {
+		return errors.Errorf("option(s) %s do not apply to %s requests", strings.Join(invalid, ", "), reqType)
+	}
+	return nil
+}
+
+//WithPolicy sets the endorsement policy expression of the chaincode, e.g. OR('Org1MSP.member','Org2MSP.member')
+func WithPolicy(policy string) Option {
+	return func(opts *Options) error {
+		opts.Policy = policy
+		return nil
+	}
+}
+
+//WithCollectionConfigFile sets the private data collection config file of the chaincode.
+//See fixtures/configs/pvtdatacollection.json for a sample config file.
+func WithCollectionConfigFile(collectionConfigFile string) Option {
+	return func(opts *Options) error {
+		opts.CollectionConfigFile = collectionConfigFile
+		return nil
+	}
+}
+
+//WithCollectionConfig sets the private data collection config of the chaincode
+func WithCollectionConfig(collConfig []*common.CollectionConfig) Option {
+	return func(opts *Options) error {
+		opts.CollectionConfig = collConfig
+		return nil
+	}
+}
+
+//WithTargets sets the peers the request is sent to
+func WithTargets(targets ...fab.Peer) Option {
+	return func(opts *Options) error {
+		if len(targets) == 0 {
+			return errors.New("at least one target peer is required")
+		}
+		opts.Targets = targets
+		return nil
+	}
+}
+
+//WithTimeout sets the timeout of the request
+func WithTimeout(timeout time.Duration) Option {
+	return func(opts *Options) error {
+		if timeout <= 0 {
+			return errors.Errorf("invalid timeout %s", timeout)
+		}
+		opts.Timeout = timeout
+		return nil
+	}
+}
+
+//WithContext cancels the execute and query requests once the context is done
+func WithContext(ctx context.Context) Option {
+	return func(opts *Options) error {
+		if ctx == nil {
+			return errors.New("context is not set")
+		}
+		opts.Context = ctx
+		return nil
+	}
+}
+
+//WithIdentity signs the request with the identity registered under the name
+func WithIdentity(name string) Option {
+	return func(opts *Options) error {
+		if name == "" {
+			return errors.New("identity name is not set")
+		}
+		opts.Identity = name
+		return nil
+	}
+}
+
+//WithConsensusRead requires a query to be evaluated on several peers, the quorum of which must return the same response.
+//Unless targets are set, the query is evaluated on all client org peers.
+func WithConsensusRead(quorum int) Option {
+	return func(opts *Options) error {
+		if quorum <= 0 {
+			return errors.Errorf("invalid consensus quorum %d", quorum)
+		}
+		opts.ConsensusQuorum = quorum
+		return nil
+	}
+}
+
+//WithNonce sets the nonce of the executed transaction so that its ID, computed by ComputeTransactionID, is known before it is submitted.
+//A nonce must only be used for a single transaction: it is rejected with an MVCC retry and by contracts and batch submitters.
+func WithNonce(nonce []byte) Option {
+	return func(opts *Options) error {
+		if len(nonce) == 0 {
+			return errors.New("nonce is not set")
+		}
+		opts.Nonce = nonce
+		return nil
+	}
+}
+
+//WithCreator sets the serialized identity expected to sign the executed transaction, see SerializeIdentity.
+//The request fails if it is signed by another identity, since the transaction ID computed with the creator would not match.
+func WithCreator(creator []byte) Option {
+	return func(opts *Options) error {
+		if len(creator) == 0 {
+			return errors.New("creator is not set")
+		}
+		opts.Creator = creator
+		return nil
+	}
+}
+
+//collectionConfig returns the collection config set in the options, loading the collection config file if needed
+func (opts Options) collectionConfig() ([]*common.CollectionConfig, error) {
+	if opts.CollectionConfig != nil {
+		return opts.CollectionConfig, nil
+	}
+	// Private Data Collection Configuration
+	// - see fixtures/config/pvtdatacollection.json for sample config file
+	return collectionConfig(opts.CollectionConfigFile)
+}
+
+func resmgmtRequestOptions(targets []fab.Peer, timeout time.Duration) []resmgmt.RequestOption {
+	reqOpts := []resmgmt.RequestOption{resmgmt.WithTargets(targets...)}
+	if timeout > 0 {
+		reqOpts = append(reqOpts, resmgmt.WithTimeout(fab.ResMgmt, timeout))
+	}
+	return reqOpts
+}
+
+func channelRequestOptions(targets []fab.Peer, timeout time.Duration, timeoutType fab.TimeoutType, ctx context.Context) []channel.RequestOption {
+	var reqOpts []channel.RequestOption
+	if ctx != nil {
+		reqOpts = append(reqOpts, channel.WithParentContext(ctx))
+	}
+	if len(targets) > 0 {
+		reqOpts = append(reqOpts, channel.WithTargets(targets...))
+	}
+	if timeout > 0 {
+		reqOpts = append(reqOpts, channel.WithTimeout(timeoutType, timeout))
+	}
+	return reqOpts
+}
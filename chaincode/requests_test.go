@@ -0,0 +1,42 @@
+package chaincode
+
+import (
+	"context"
+	"testing"
+)
+
+func TestOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		reqType RequestType
+		valid   bool
+	}{
+		{name: "targets and timeout on install", opts: []Option{WithTimeout(1)}, reqType: InstallRequestType, valid: true},
+		{name: "policy on upgrade", opts: []Option{WithPolicy("OR('Org1MSP.member')")}, reqType: UpgradeRequestType, valid: true},
+		{name: "policy on execute", opts: []Option{WithPolicy("OR('Org1MSP.member')")}, reqType: ExecuteRequestType},
+		{name: "consensus read on query", opts: []Option{WithConsensusRead(2)}, reqType: QueryRequestType, valid: true},
+		{name: "consensus read on execute", opts: []Option{WithConsensusRead(2)}, reqType: ExecuteRequestType},
+		{name: "commit wait on execute", opts: []Option{WithCommitWait(CommitWait{Strategy: CommitAllPeersInOrg})}, reqType: ExecuteRequestType, valid: true},
+		{name: "commit wait on query", opts: []Option{WithCommitWait(CommitWait{Strategy: CommitAllPeersInOrg})}, reqType: QueryRequestType},
+		{name: "commit wait on asynchronous execute", opts: []Option{WithCommitWait(CommitWait{Strategy: CommitAllPeersInOrg})}, reqType: ExecuteAsyncRequestType},
+		{name: "MVCC retry on execute", opts: []Option{WithMVCCRetry(MVCCRetry{Attempts: 3})}, reqType: ExecuteRequestType, valid: true},
+		{name: "MVCC retry on query", opts: []Option{WithMVCCRetry(MVCCRetry{Attempts: 3})}, reqType: QueryRequestType},
+		{name: "MVCC retry on asynchronous execute", opts: []Option{WithMVCCRetry(MVCCRetry{Attempts: 3})}, reqType: ExecuteAsyncRequestType},
+		{name: "nonce on simulate", opts: []Option{WithNonce([]byte("nonce"))}, reqType: SimulateRequestType, valid: true},
+		{name: "nonce on query", opts: []Option{WithNonce([]byte("nonce"))}, reqType: QueryRequestType},
+		{name: "context on query", opts: []Option{WithContext(context.Background())}, reqType: QueryRequestType, valid: true},
+		{name: "context on install", opts: []Option{WithContext(context.Background())}, reqType: InstallRequestType},
+		{name: "identity on offline", opts: []Option{WithIdentity("user2")}, reqType: OfflineRequestType},
+		{name: "codec on execute", opts: []Option{WithCodec(JSONCodec)}, reqType: ExecuteRequestType},
+	}
+	for _, test := range tests {
+		options, err := NewOptions(test.opts...)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		if err := options.Validate(test.reqType); (err == nil) != test.valid {
+			t.Errorf("%s: error %v, expected valid %t", test.name, err, test.valid)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package chaincode
+
+import (
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+//List of deployment result infos
+const (
+	InfoOK     = "OK"
+	InfoExists = "EXISTS"
+	InfoFailed = "FAILED"
+)
+
+//PeerStatus is the outcome of the install of a chaincode on a peer
+type PeerStatus struct {
+	Peer string
+	//Info is OK, EXISTS if the chaincode was already installed on the peer, or FAILED
+	Info string
+	//Message is the error returned by the peer, only set if the install failed
+	Message string
+}
+
+//Result is the outcome of a chaincode invocation or deployment
+type Result struct {
+	TxID string
+	//ValidationCode is the commit validation code of the transaction, NOT_VALIDATED for queries and installs
+	ValidationCode pb.TxValidationCode
+	//ChaincodeStatus is the status returned by the chaincode, e.g. 200
+	ChaincodeStatus int32
+	//Endorsers are the URLs of the peers that endorsed, or for installs installed, the request
+	Endorsers []string
+	//BlockNumber is the number of the block the transaction was committed in, only set for executed transactions
+	BlockNumber uint64
+	Payload     []byte
+	//Info describes the deployment outcome, e.g. OK or EXISTS
+	Info      string
+	Responses []*fab.TransactionProposalResponse
+	//Endorsement compares the responses of the endorsers, only set for consensus reads
+	Endorsement *EndorsementReport
+	//Attempts is the number of times the transaction was endorsed and submitted, only set for executed transactions
+	Attempts int
+	//Peers are the outcomes of an install on each target peer, only set for installs
+	Peers []PeerStatus
+}
+
+//PayloadString returns the payload as a string
+func (r *Result) PayloadString() string {
+	return string(r.Payload)
+}
+
+//Valid reports whether the transaction was committed as valid
+func (r *Result) Valid() bool {
+	return r.ValidationCode == pb.TxValidationCode_VALID
+}
+
+func newResult(response channel.Response) *Result {
+	result := new(Result)
+	result.TxID = string(response.TransactionID)
+	result.ValidationCode = response.TxValidationCode
+	result.ChaincodeStatus = response.ChaincodeStatus
+	result.Payload = response.Payload
+	result.Responses = response.Responses
+	for _, r := range response.Responses {
+		result.Endorsers = append(result.Endorsers, r.Endorser)
+	}
+	return result
+}
+
+//deployResult returns the result of a deployment whose payload is the info, as returned by the deployment clients before results
+func deployResult(info string, txID string, validationCode pb.TxValidationCode, targets []fab.Peer) *Result {
+	result := new(Result)
+	result.TxID = txID
+	result.ValidationCode = validationCode
+	result.Payload = []byte(info)
+	result.Info = info
+	for _, target := range targets {
+		result.Endorsers = append(result.Endorsers, target.URL())
+	}
+	return result
+}
@@ -0,0 +1,86 @@
+package chaincode
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"github.com/pkg/errors"
+)
+
+//MVCCRetry defines how an executed transaction invalidated by an MVCC read conflict is endorsed and submitted again
+type MVCCRetry struct {
+	//Attempts is the maximum number of attempts, including the first one
+	Attempts int
+	//Backoff is the wait before the second attempt, doubled on each following attempt
+	Backoff time.Duration
+	//MaxBackoff caps the wait between attempts, not capped if 0
+	MaxBackoff time.Duration
+}
+
+//WithMVCCRetry endorses and submits again an executed transaction invalidated by an MVCC read conflict,
+//up to the attempts of the retry with an exponential backoff.
+//The conflict is detected from the commit event, so it does not apply with the CommitNoWait strategy nor to asynchronous submits.
+func WithMVCCRetry(retry MVCCRetry) Option {
+	return func(opts *Options) error {
+		if retry.Attempts < 1 {
+			return errors.Errorf("invalid MVCC retry attempts %d", retry.Attempts)
+		}
+		if retry.Backoff < 0 || retry.MaxBackoff < 0 {
+			return errors.New("invalid MVCC retry backoff")
+		}
+		opts.MVCCRetry = &retry
+		return nil
+	}
+}
+
+//backoff returns the wait before the attempt, attempts starting at 1
+func (r MVCCRetry) backoff(attempt int) time.Duration {
+	return exponentialBackoff(r.Backoff, r.MaxBackoff, attempt)
+}
+
+//exponentialBackoff returns the wait before the attempt, doubling the initial backoff from the third attempt on, capped by the maximum if set
+func exponentialBackoff(backoff time.Duration, maxBackoff time.Duration, attempt int) time.Duration {
+	for i := 2; i < attempt; i++ {
+		//Doubling stops once the maximum is reached, or before overflowing if the backoff is not capped
+		if (maxBackoff > 0 && backoff >= maxBackoff) || backoff > math.MaxInt64/2 {
+			break
+		}
+		backoff *= 2
+	}
+	if maxBackoff > 0 && backoff > maxBackoff {
+		return maxBackoff
+	}
+	return backoff
+}
+
+//IsMVCCReadConflict reports whether the error is caused by the invalidation of the transaction for an MVCC read conflict
+func IsMVCCReadConflict(err error) bool {
+	s, ok := status.FromError(err)
+	return ok && s.Group == status.EventServerStatus && s.Code == int32(pb.TxValidationCode_MVCC_READ_CONFLICT)
+}
+
+//retryOnMVCCReadConflict calls invoke until it does not fail with an MVCC read conflict, the attempts are exhausted or the context is done
+func retryOnMVCCReadConflict(ctx context.Context, retry MVCCRetry, invoke func() (*Result, error)) (*Result, error) {
+	for attempt := 1; ; attempt++ {
+		result, err := invoke()
+		if err == nil {
+			result.Attempts = attempt
+			return result, nil
+		}
+		if !IsMVCCReadConflict(err) {
+			return nil, err
+		}
+		if attempt >= retry.Attempts {
+			return nil, errors.WithMessage(err, fmt.Sprintf("transaction still invalidated by an MVCC read conflict after %d attempts", attempt))
+		}
+		select {
+		case <-time.After(retry.backoff(attempt + 1)):
+		case <-ctx.Done():
+			return nil, errors.Wrapf(ctx.Err(), "retry of transaction invalidated by an MVCC read conflict cancelled after %d attempts", attempt)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package chaincode
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"github.com/pkg/errors"
+)
+
+func mvccReadConflict() error {
+	return status.New(status.EventServerStatus, int32(pb.TxValidationCode_MVCC_READ_CONFLICT), "transaction invalidated", nil)
+}
+
+func TestMVCCRetryBackoff(t *testing.T) {
+	tests := []struct {
+		retry   MVCCRetry
+		attempt int
+		want    time.Duration
+	}{
+		{retry: MVCCRetry{Backoff: 100 * time.Millisecond}, attempt: 2, want: 100 * time.Millisecond},
+		{retry: MVCCRetry{Backoff: 100 * time.Millisecond}, attempt: 3, want: 200 * time.Millisecond},
+		{retry: MVCCRetry{Backoff: 100 * time.Millisecond}, attempt: 5, want: 800 * time.Millisecond},
+		{retry: MVCCRetry{Backoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}, attempt: 4, want: 300 * time.Millisecond},
+		{retry: MVCCRetry{Backoff: time.Hour, MaxBackoff: time.Minute}, attempt: 100, want: time.Minute},
+		{retry: MVCCRetry{Backoff: time.Second, MaxBackoff: time.Hour}, attempt: 100, want: time.Hour},
+		{retry: MVCCRetry{Backoff: time.Second}, attempt: 100, want: time.Second << 33},
+	}
+	for _, test := range tests {
+		if got := test.retry.backoff(test.attempt); got != test.want {
+			t.Errorf("backoff of attempt %d with %+v = %s, want %s", test.attempt, test.retry, got, test.want)
+		}
+	}
+}
+
+func TestRetryOnMVCCReadConflict(t *testing.T) {
+	otherErr := errors.New("endorsement failed")
+	tests := []struct {
+		name     string
+		attempts int
+		errs     []error
+		calls    int
+		err      bool
+	}{
+		{name: "no conflict", attempts: 3, errs: []error{nil}, calls: 1},
+		{name: "conflict then success", attempts: 3, errs: []error{mvccReadConflict(), mvccReadConflict(), nil}, calls: 3},
+		{name: "attempts exhausted", attempts: 2, errs: []error{mvccReadConflict(), mvccReadConflict(), nil}, calls: 2, err: true},
+		{name: "other error not retried", attempts: 3, errs: []error{otherErr, nil}, calls: 1, err: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			calls := 0
+			result, err := retryOnMVCCReadConflict(context.Background(), MVCCRetry{Attempts: test.attempts}, func() (*Result, error) {
+				err := test.errs[calls]
+				calls++
+				if err != nil {
+					return nil, err
+				}
+				return &Result{}, nil
+			})
+			if calls != test.calls {
+				t.Errorf("expected %d calls, got %d", test.calls, calls)
+			}
+			if test.err {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Attempts != test.calls {
+				t.Errorf("expected %d attempts in the result, got %d", test.calls, result.Attempts)
+			}
+		})
+	}
+}
+
+func TestRetryOnMVCCReadConflictCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	done := make(chan error)
+	go func() {
+		_, err := retryOnMVCCReadConflict(ctx, MVCCRetry{Attempts: 3, Backoff: time.Hour}, func() (*Result, error) {
+			calls++
+			return nil, mvccReadConflict()
+		})
+		done <- err
+	}()
+	cancel()
+	select {
+	case err := <-done:
+		if errors.Cause(err) != context.Canceled {
+			t.Errorf("expected the backoff to be cancelled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("backoff not cancelled with the context")
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 call before the cancellation, got %d", calls)
+	}
+}
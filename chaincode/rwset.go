@@ -0,0 +1,199 @@
+package chaincode
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/ledger/rwset"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/ledger/rwset/kvrwset"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"github.com/pkg/errors"
+)
+
+//chaincodeAction extracts the chaincode action of a proposal response
+func chaincodeAction(response *fab.TransactionProposalResponse) (*pb.ChaincodeAction, error) {
+	if response == nil || response.ProposalResponse == nil {
+		return nil, errors.New("proposal response is not set")
+	}
+	prp := new(pb.ProposalResponsePayload)
+	if err := proto.Unmarshal(response.ProposalResponse.Payload, prp); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal proposal response payload")
+	}
+	action := new(pb.ChaincodeAction)
+	if err := proto.Unmarshal(prp.Extension, action); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal chaincode action")
+	}
+	return action, nil
+}
+
+//hasWrites reports whether the simulation results of the proposal response write public or private state
+func hasWrites(response *fab.TransactionProposalResponse) (bool, error) {
+	action, err := chaincodeAction(response)
+	if err != nil {
+		return false, err
+	}
+	txRWSet := new(rwset.TxReadWriteSet)
+	if err := proto.Unmarshal(action.Results, txRWSet); err != nil {
+		return false, errors.Wrap(err, "failed to unmarshal read/write set")
+	}
+	for _, nsRWSet := range txRWSet.NsRwset {
+		kvRWSet := new(kvrwset.KVRWSet)
+		if err := proto.Unmarshal(nsRWSet.Rwset, kvRWSet); err != nil {
+			return false, errors.Wrapf(err, "failed to unmarshal read/write set of namespace %s", nsRWSet.Namespace)
+		}
+		if len(kvRWSet.Writes) > 0 || len(kvRWSet.MetadataWrites) > 0 {
+			return true, nil
+		}
+		for _, collRWSet := range nsRWSet.CollectionHashedRwset {
+			hashedRWSet := new(kvrwset.HashedRWSet)
+			if err := proto.Unmarshal(collRWSet.HashedRwset, hashedRWSet); err != nil {
+				return false, errors.Wrapf(err, "failed to unmarshal hashed read/write set of collection %s", collRWSet.CollectionName)
+			}
+			if len(hashedRWSet.HashedWrites) > 0 || len(hashedRWSet.MetadataWrites) > 0 {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+//ReadWriteSet is the decoded read/write set of a transaction
+type ReadWriteSet struct {
+	Namespaces []NamespaceReadWriteSet `json:"namespaces"`
+}
+
+//NamespaceReadWriteSet is the decoded read/write set of a chaincode namespace
+type NamespaceReadWriteSet struct {
+	Namespace    string                   `json:"namespace"`
+	Reads        []KVRead                 `json:"reads,omitempty"`
+	Writes       []KVWrite                `json:"writes,omitempty"`
+	RangeQueries []RangeQuery             `json:"rangeQueries,omitempty"`
+	Collections  []CollectionReadWriteSet `json:"collections,omitempty"`
+}
+
+//Version is the version of a key, the block and transaction numbers of its last write
+type Version struct {
+	BlockNum uint64 `json:"blockNum"`
+	TxNum    uint64 `json:"txNum"`
+}
+
+//KVRead is a key read at a version, nil if the key did not exist
+type KVRead struct {
+	Key     string   `json:"key"`
+	Version *Version `json:"version"`
+}
+
+//KVWrite is a key written or deleted
+type KVWrite struct {
+	Key      string `json:"key"`
+	IsDelete bool   `json:"isDelete,omitempty"`
+	Value    Bytes  `json:"value,omitempty"`
+}
+
+//RangeQuery is a range of keys read
+type RangeQuery struct {
+	StartKey     string   `json:"startKey"`
+	EndKey       string   `json:"endKey"`
+	ItrExhausted bool     `json:"itrExhausted"`
+	Reads        []KVRead `json:"reads,omitempty"`
+}
+
+//CollectionReadWriteSet is the hashed read/write set of a private data collection
+type CollectionReadWriteSet struct {
+	Collection   string        `json:"collection"`
+	HashedReads  []HashedRead  `json:"hashedReads,omitempty"`
+	HashedWrites []HashedWrite `json:"hashedWrites,omitempty"`
+}
+
+//HashedRead is a private key read, identified by its hash
+type HashedRead struct {
+	KeyHash Hash     `json:"keyHash"`
+	Version *Version `json:"version"`
+}
+
+//HashedWrite is a private key written or deleted, identified by its hash
+type HashedWrite struct {
+	KeyHash   Hash `json:"keyHash"`
+	IsDelete  bool `json:"isDelete,omitempty"`
+	ValueHash Hash `json:"valueHash,omitempty"`
+}
+
+//ChaincodeEvent is the event set by the chaincode
+type ChaincodeEvent struct {
+	ChaincodeID string `json:"chaincodeId"`
+	TxID        string `json:"txId"`
+	EventName   string `json:"eventName"`
+	Payload     Bytes  `json:"payload,omitempty"`
+}
+
+//decodeReadWriteSet decodes the simulation results of a chaincode action
+func decodeReadWriteSet(results []byte) (*ReadWriteSet, error) {
+	txRWSet := new(rwset.TxReadWriteSet)
+	if err := proto.Unmarshal(results, txRWSet); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal read/write set")
+	}
+	decoded := new(ReadWriteSet)
+	for _, nsRWSet := range txRWSet.NsRwset {
+		kvRWSet := new(kvrwset.KVRWSet)
+		if err := proto.Unmarshal(nsRWSet.Rwset, kvRWSet); err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal read/write set of namespace %s", nsRWSet.Namespace)
+		}
+		ns := NamespaceReadWriteSet{Namespace: nsRWSet.Namespace}
+		ns.Reads = decodeReads(kvRWSet.Reads)
+		for _, write := range kvRWSet.Writes {
+			ns.Writes = append(ns.Writes, KVWrite{Key: write.Key, IsDelete: write.IsDelete, Value: write.Value})
+		}
+		for _, rangeQuery := range kvRWSet.RangeQueriesInfo {
+			decodedQuery := RangeQuery{StartKey: rangeQuery.StartKey, EndKey: rangeQuery.EndKey, ItrExhausted: rangeQuery.ItrExhausted}
+			if rawReads := rangeQuery.GetRawReads(); rawReads != nil {
+				decodedQuery.Reads = decodeReads(rawReads.KvReads)
+			}
+			ns.RangeQueries = append(ns.RangeQueries, decodedQuery)
+		}
+		for _, collRWSet := range nsRWSet.CollectionHashedRwset {
+			hashedRWSet := new(kvrwset.HashedRWSet)
+			if err := proto.Unmarshal(collRWSet.HashedRwset, hashedRWSet); err != nil {
+				return nil, errors.Wrapf(err, "failed to unmarshal hashed read/write set of collection %s", collRWSet.CollectionName)
+			}
+			coll := CollectionReadWriteSet{Collection: collRWSet.CollectionName}
+			for _, read := range hashedRWSet.HashedReads {
+				coll.HashedReads = append(coll.HashedReads, HashedRead{KeyHash: read.KeyHash, Version: decodeVersion(read.Version)})
+			}
+			for _, write := range hashedRWSet.HashedWrites {
+				coll.HashedWrites = append(coll.HashedWrites, HashedWrite{KeyHash: write.KeyHash, IsDelete: write.IsDelete, ValueHash: write.ValueHash})
+			}
+			ns.Collections = append(ns.Collections, coll)
+		}
+		decoded.Namespaces = append(decoded.Namespaces, ns)
+	}
+	return decoded, nil
+}
+
+func decodeReads(reads []*kvrwset.KVRead) []KVRead {
+	var decoded []KVRead
+	for _, read := range reads {
+		decoded = append(decoded, KVRead{Key: read.Key, Version: decodeVersion(read.Version)})
+	}
+	return decoded
+}
+
+func decodeVersion(version *kvrwset.Version) *Version {
+	if version == nil {
+		return nil
+	}
+	return &Version{BlockNum: version.BlockNum, TxNum: version.TxNum}
+}
+
+//decodeChaincodeEvent decodes the chaincode event of a chaincode action, nil if the chaincode did not set any
+func decodeChaincodeEvent(events []byte) (*ChaincodeEvent, error) {
+	if len(events) == 0 {
+		return nil, nil
+	}
+	event := new(pb.ChaincodeEvent)
+	if err := proto.Unmarshal(events, event); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal chaincode event")
+	}
+	if event.EventName == "" && len(event.Payload) == 0 {
+		return nil, nil
+	}
+	return &ChaincodeEvent{ChaincodeID: event.ChaincodeId, TxID: event.TxId, EventName: event.EventName, Payload: event.Payload}, nil
+}
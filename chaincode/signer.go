@@ -0,0 +1,299 @@
+package chaincode
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
+	mspapi "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
+	"github.com/pkg/errors"
+)
+
+//SignerIdentity is the identity of a signer: its MSP and its PEM enrollment certificate
+type SignerIdentity struct {
+	MSPID       string `json:"mspId"`
+	Certificate []byte `json:"certificate"`
+}
+
+//Signer signs the proposals and envelopes of transactions with a private key the SDK has no access to
+type Signer interface {
+	//Identity returns the identity the signatures are verified with
+	Identity() (SignerIdentity, error)
+	//Sign returns the ASN.1 DER ECDSA signature, with a low S, of the SHA-256 digest of the message, as expected by Fabric
+	Sign(message []byte) ([]byte, error)
+}
+
+//pemSigner signs in process with an ECDSA private key
+type pemSigner struct {
+	identity SignerIdentity
+	key      *ecdsa.PrivateKey
+}
+
+//NewPEMSigner returns a Signer signing in process with the PEM ECDSA private key of the identity
+func NewPEMSigner(mspID string, certPEM []byte, keyPEM []byte) (Signer, error) {
+	if mspID == "" {
+		return nil, errors.New("MSP ID is not set")
+	}
+	if block, _ := pem.Decode(certPEM); block == nil {
+		return nil, errors.New("certificate is not PEM encoded")
+	}
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("private key is not PEM encoded")
+	}
+	var key *ecdsa.PrivateKey
+	if parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		ecKey, ok := parsed.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("private key is not an ECDSA key")
+		}
+		key = ecKey
+	} else {
+		ecKey, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse private key")
+		}
+		key = ecKey
+	}
+	return &pemSigner{identity: SignerIdentity{MSPID: mspID, Certificate: certPEM}, key: key}, nil
+}
+
+func (s *pemSigner) Identity() (SignerIdentity, error) {
+	return s.identity, nil
+}
+
+func (s *pemSigner) Sign(message []byte) ([]byte, error) {
+	digest := sha256.Sum256(message)
+	r, sig, err := ecdsa.Sign(rand.Reader, s.key, digest[:])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sign")
+	}
+	//Fabric only accepts signatures with a low S
+	halfOrder := new(big.Int).Rsh(s.key.Params().N, 1)
+	if sig.Cmp(halfOrder) > 0 {
+		sig.Sub(s.key.Params().N, sig)
+	}
+	return asn1.Marshal(ecdsaSignature{R: r, S: sig})
+}
+
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+//signerSigningIdentity is the SDK identity of a signer, the SDK contexts of the offline client being created for the signer
+//rather than for an identity of the client org loaded from its keystore
+type signerSigningIdentity struct {
+	identity SignerIdentity
+	id       string
+	signer   Signer
+}
+
+//newSignerSigningIdentity returns the SDK identity of the signer, identified by the common name of its certificate
+func newSignerSigningIdentity(signer Signer) (*signerSigningIdentity, error) {
+	identity, err := signer.Identity()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get the signer identity")
+	}
+	if identity.MSPID == "" {
+		return nil, errors.New("signer MSP ID is not set")
+	}
+	block, _ := pem.Decode(identity.Certificate)
+	if block == nil {
+		return nil, errors.New("signer certificate is not PEM encoded")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse signer certificate")
+	}
+	return &signerSigningIdentity{identity: identity, id: cert.Subject.CommonName, signer: signer}, nil
+}
+
+func (s *signerSigningIdentity) Identifier() *mspapi.IdentityIdentifier {
+	return &mspapi.IdentityIdentifier{MSPID: s.identity.MSPID, ID: s.id}
+}
+
+func (s *signerSigningIdentity) Verify(msg []byte, sig []byte) error {
+	return verifySignature(s.identity.Certificate, msg, sig)
+}
+
+func (s *signerSigningIdentity) Serialize() ([]byte, error) {
+	return SerializeIdentity(s.identity)
+}
+
+func (s *signerSigningIdentity) EnrollmentCertificate() []byte {
+	return s.identity.Certificate
+}
+
+func (s *signerSigningIdentity) Sign(msg []byte) ([]byte, error) {
+	return s.signer.Sign(msg)
+}
+
+func (s *signerSigningIdentity) PublicVersion() mspapi.Identity {
+	return s
+}
+
+//PrivateKey returns nil, the private key of the signer being out of reach of the SDK.
+//The offline client signs its proposals and envelopes with the signer and never with the signing manager of the SDK.
+func (s *signerSigningIdentity) PrivateKey() core.Key {
+	return nil
+}
+
+//signRequest and signResponse are the JSON messages of the HTTP signing service
+type signRequest struct {
+	Message []byte `json:"message"`
+}
+
+type signResponse struct {
+	Signature []byte `json:"signature"`
+}
+
+//httpSigner delegates the signatures to an HTTP signing service
+type httpSigner struct {
+	url      string
+	client   *http.Client
+	mutex    sync.Mutex
+	identity *SignerIdentity
+}
+
+//NewHTTPSigner returns a Signer delegating to the signing service at the URL.
+//The service returns the signer identity as JSON on GET <url>/identity and signs the base64 message posted as JSON on POST <url>/sign.
+//See NewSignerHandler for the protocol of the service.
+func NewHTTPSigner(url string, client *http.Client) (Signer, error) {
+	if url == "" {
+		return nil, errors.New("signing service URL is not set")
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &httpSigner{url: strings.TrimSuffix(url, "/"), client: client}, nil
+}
+
+func (s *httpSigner) Identity() (SignerIdentity, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.identity != nil {
+		return *s.identity, nil
+	}
+	resp, err := s.client.Get(s.url + "/identity")
+	if err != nil {
+		return SignerIdentity{}, errors.Wrap(err, "failed to get the signer identity")
+	}
+	var identity SignerIdentity
+	if err := decodeSignerResponse(resp, &identity); err != nil {
+		return SignerIdentity{}, errors.WithMessage(err, "failed to get the signer identity")
+	}
+	s.identity = &identity
+	return identity, nil
+}
+
+func (s *httpSigner) Sign(message []byte) ([]byte, error) {
+	body, err := json.Marshal(signRequest{Message: message})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal sign request")
+	}
+	resp, err := s.client.Post(s.url+"/sign", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to send sign request")
+	}
+	var signed signResponse
+	if err := decodeSignerResponse(resp, &signed); err != nil {
+		return nil, errors.WithMessage(err, "failed to sign")
+	}
+	if len(signed.Signature) == 0 {
+		return nil, errors.New("signing service returned an empty signature")
+	}
+	return signed.Signature, nil
+}
+
+func decodeSignerResponse(resp *http.Response, v interface{}) error {
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return errors.Errorf("signing service returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return errors.Wrap(err, "failed to decode signing service response")
+	}
+	return nil
+}
+
+//NewSignerHandler returns the HTTP handler of a signing service backed by the signer, e.g. a local stand-in of the signing service
+//of an HTTP signer in tests:
+//	GET /identity returns {"mspId": "...", "certificate": "<base64 PEM>"}
+//	POST /sign {"message": "<base64>"} returns {"signature": "<base64>"}
+func NewSignerHandler(signer Signer) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/identity", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		identity, err := signer.Identity()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeSignerResponse(w, identity)
+	})
+	mux.HandleFunc("/sign", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var req signRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid sign request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		signature, err := signer.Sign(req.Message)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeSignerResponse(w, signResponse{Signature: signature})
+	})
+	return mux
+}
+
+func writeSignerResponse(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+//verifySignature checks the signature of the message against the public key of the PEM certificate
+func verifySignature(certPEM []byte, message []byte, signature []byte) error {
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return errors.New("certificate is not PEM encoded")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse certificate")
+	}
+	publicKey, ok := cert.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return errors.New("certificate public key is not an ECDSA key")
+	}
+	var sig ecdsaSignature
+	if _, err := asn1.Unmarshal(signature, &sig); err != nil {
+		return errors.Wrap(err, "failed to unmarshal signature")
+	}
+	digest := sha256.Sum256(message)
+	if !ecdsa.Verify(publicKey, digest[:], sig.R, sig.S) {
+		return errors.New("signature does not match the signer certificate")
+	}
+	return nil
+}
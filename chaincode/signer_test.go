@@ -0,0 +1,128 @@
+package chaincode
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+//newTestPEMSigner returns an in-process signer with a self-signed certificate
+func newTestPEMSigner(t *testing.T) Signer {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "User1@org1.example.com"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	signer, err := NewPEMSigner("Org1MSP", certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func TestHTTPSignerRoundTrip(t *testing.T) {
+	pemSigner := newTestPEMSigner(t)
+	server := httptest.NewServer(NewSignerHandler(pemSigner))
+	defer server.Close()
+
+	signer, err := NewHTTPSigner(server.URL, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity, err := signer.Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := pemSigner.Identity()
+	if identity.MSPID != expected.MSPID || string(identity.Certificate) != string(expected.Certificate) {
+		t.Fatalf("identity %s does not match the identity of the stand-in signer %s", identity.MSPID, expected.MSPID)
+	}
+
+	message := []byte("proposal bytes")
+	//Signatures are randomized, several are checked to cover the low S normalization
+	for i := 0; i < 10; i++ {
+		signature, err := signer.Sign(message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := verifySignature(identity.Certificate, message, signature); err != nil {
+			t.Fatal(err)
+		}
+	}
+	signature, err := signer.Sign(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifySignature(identity.Certificate, []byte("other bytes"), signature); err == nil {
+		t.Fatal("signature of another message was accepted")
+	}
+}
+
+func TestHTTPSignerError(t *testing.T) {
+	server := httptest.NewServer(NewSignerHandler(newTestPEMSigner(t)))
+	defer server.Close()
+
+	signer, err := NewHTTPSigner(server.URL+"/missing", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := signer.Sign([]byte("proposal bytes")); err == nil {
+		t.Fatal("signing through an unknown endpoint succeeded")
+	}
+}
+
+func TestSignerSigningIdentity(t *testing.T) {
+	signer := newTestPEMSigner(t)
+	identity, err := newSignerSigningIdentity(signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := identity.Identifier(); id.MSPID != "Org1MSP" || id.ID != "User1@org1.example.com" {
+		t.Errorf("identifier %s/%s, expected Org1MSP/User1@org1.example.com", id.MSPID, id.ID)
+	}
+	message := []byte("proposal")
+	signature, err := identity.Sign(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := identity.Verify(message, signature); err != nil {
+		t.Errorf("signature of the signer does not verify: %s", err)
+	}
+	if err := identity.Verify([]byte("other proposal"), signature); err == nil {
+		t.Error("signature of another message verified")
+	}
+	signerIdentity, _ := signer.Identity()
+	expected, err := SerializeIdentity(signerIdentity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serialized, err := identity.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(serialized) != string(expected) {
+		t.Error("serialized identity does not match the serialized signer identity")
+	}
+}
@@ -0,0 +1,59 @@
+package chaincode
+
+import (
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+//Simulation is the outcome of a transaction endorsed but not sent to the orderer
+type Simulation struct {
+	TxID            string                 `json:"txId"`
+	Payload         Bytes                  `json:"payload,omitempty"`
+	ChaincodeStatus int32                  `json:"chaincodeStatus"`
+	Endorsements    []SimulatedEndorsement `json:"endorsements"`
+	//Endorsement compares the responses of the endorsers
+	Endorsement *EndorsementReport `json:"consistency"`
+}
+
+//SimulatedEndorsement is the decoded proposal response of an endorser
+type SimulatedEndorsement struct {
+	Endorser string        `json:"endorser"`
+	Status   int32         `json:"status"`
+	Message  string        `json:"message,omitempty"`
+	Payload  Bytes         `json:"payload,omitempty"`
+	RWSet    *ReadWriteSet `json:"rwset"`
+	//Event is the chaincode event the transaction would emit, nil if none
+	Event *ChaincodeEvent `json:"event,omitempty"`
+}
+
+//newSimulateHandler returns the handler chain endorsing a transaction without validating the endorsements nor sending it to the orderer.
+//The endorsers that failed are collected in the failures.
+func newSimulateHandler(header *transactionHeader, failures *endorsementFailures) invoke.Handler {
+	return invoke.NewProposalProcessorHandler(newEndorsementHandler(header, failures, nil))
+}
+
+//JSON renders the simulation as indented JSON
+func (s *Simulation) JSON() ([]byte, error) {
+	return renderJSON(s)
+}
+
+//DecodeEndorsement decodes the proposal response of an endorser, e.g. one of the responses of a Result
+func DecodeEndorsement(response *fab.TransactionProposalResponse) (SimulatedEndorsement, error) {
+	endorsement := SimulatedEndorsement{Endorser: response.Endorser}
+	if response.ProposalResponse != nil && response.ProposalResponse.Response != nil {
+		endorsement.Status = response.ProposalResponse.Response.Status
+		endorsement.Message = response.ProposalResponse.Response.Message
+		endorsement.Payload = response.ProposalResponse.Response.Payload
+	}
+	action, err := chaincodeAction(response)
+	if err != nil {
+		return endorsement, err
+	}
+	if endorsement.RWSet, err = decodeReadWriteSet(action.Results); err != nil {
+		return endorsement, err
+	}
+	if endorsement.Event, err = decodeChaincodeEvent(action.Events); err != nil {
+		return endorsement, err
+	}
+	return endorsement, nil
+}
@@ -0,0 +1,97 @@
+package chaincode
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/msp"
+	"github.com/pkg/errors"
+)
+
+//NonceSize is the size of the random nonce of a transaction, as generated by Fabric
+const NonceSize = 24
+
+//NewNonce returns a random transaction nonce
+func NewNonce() ([]byte, error) {
+	nonce := make([]byte, NonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, errors.Wrap(err, "failed to generate nonce")
+	}
+	return nonce, nil
+}
+
+//SerializeIdentity returns the serialized MSP identity of the signer, the creator of the transactions it signs
+func SerializeIdentity(identity SignerIdentity) ([]byte, error) {
+	if identity.MSPID == "" || len(identity.Certificate) == 0 {
+		return nil, errors.New("invalid signer identity, missing required field(s): MSPID, Certificate")
+	}
+	creator, err := proto.Marshal(&msp.SerializedIdentity{Mspid: identity.MSPID, IdBytes: identity.Certificate})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal signer identity")
+	}
+	return creator, nil
+}
+
+//ComputeTransactionID returns the ID of the transaction with the nonce and serialized creator identity,
+//the hex SHA-256 digest of the nonce followed by the creator as computed by the peers
+func ComputeTransactionID(nonce []byte, creator []byte) string {
+	digest := sha256.New()
+	digest.Write(nonce)
+	digest.Write(creator)
+	return hex.EncodeToString(digest.Sum(nil))
+}
+
+//transactionHeader is the header of a transaction with a nonce and creator chosen by the caller
+type transactionHeader struct {
+	txID      fab.TransactionID
+	creator   []byte
+	nonce     []byte
+	channelID string
+}
+
+//newTransactionHeader returns the header of the transaction of the creator on the channel, with a random nonce unless one is set
+func newTransactionHeader(channelID string, nonce []byte, creator []byte) (*transactionHeader, error) {
+	if len(creator) == 0 {
+		return nil, errors.New("transaction creator is not set")
+	}
+	if len(nonce) == 0 {
+		var err error
+		if nonce, err = NewNonce(); err != nil {
+			return nil, err
+		}
+	}
+	return &transactionHeader{
+		txID:      fab.TransactionID(ComputeTransactionID(nonce, creator)),
+		creator:   creator,
+		nonce:     nonce,
+		channelID: channelID,
+	}, nil
+}
+
+func (h *transactionHeader) TransactionID() fab.TransactionID {
+	return h.txID
+}
+
+func (h *transactionHeader) Creator() []byte {
+	return h.creator
+}
+
+func (h *transactionHeader) Nonce() []byte {
+	return h.nonce
+}
+
+func (h *transactionHeader) ChannelID() string {
+	return h.channelID
+}
+
+//checkCreator checks that the creator set in the options, if any, is the serialized identity signing the transaction
+func checkCreator(creator []byte, signingCreator []byte) error {
+	if len(creator) > 0 && !bytes.Equal(creator, signingCreator) {
+		return errors.New("transaction creator does not match the identity signing the transaction")
+	}
+	return nil
+}
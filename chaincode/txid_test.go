@@ -0,0 +1,113 @@
+package chaincode
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+//testCertificate is the certificate of the test signer, only its bytes matter to the transaction ID
+const testCertificate = "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"
+
+//testCreatorHex is the serialized identity of Org1MSP with the test certificate
+const testCreatorHex = "0a074f7267314d5350123b2d2d2d2d2d424547494e2043455254494649434154452d2d2d2d2d0a4d4949420a2d2d2d2d2d454e442043455254494649434154452d2d2d2d2d0a"
+
+func testNonce() []byte {
+	nonce := make([]byte, NonceSize)
+	for i := range nonce {
+		nonce[i] = byte(i)
+	}
+	return nonce
+}
+
+func TestSerializeIdentity(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity SignerIdentity
+		want     string
+		err      bool
+	}{
+		{name: "valid identity", identity: SignerIdentity{MSPID: "Org1MSP", Certificate: []byte(testCertificate)}, want: testCreatorHex},
+		{name: "missing MSP ID", identity: SignerIdentity{Certificate: []byte(testCertificate)}, err: true},
+		{name: "missing certificate", identity: SignerIdentity{MSPID: "Org1MSP"}, err: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			creator, err := SerializeIdentity(test.identity)
+			if test.err {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hex.EncodeToString(creator); got != test.want {
+				t.Errorf("expected %s, got %s", test.want, got)
+			}
+		})
+	}
+}
+
+func TestComputeTransactionID(t *testing.T) {
+	creator, err := hex.DecodeString(testCreatorHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//The SHA-256 digest of the nonce followed by the creator, computed independently of the SDK
+	const want = "48ec492efd1359c266462f6dec87936fb13a9e2dbe563b095ad3ed4ee4a974e3"
+	if txID := ComputeTransactionID(testNonce(), creator); txID != want {
+		t.Errorf("expected transaction ID %s, got %s", want, txID)
+	}
+}
+
+func TestNewTransactionHeader(t *testing.T) {
+	creator, err := hex.DecodeString(testCreatorHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header, err := newTransactionHeader("mychannel", testNonce(), creator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(header.Nonce(), testNonce()) || !bytes.Equal(header.Creator(), creator) || header.ChannelID() != "mychannel" {
+		t.Errorf("unexpected header %+v", header)
+	}
+	if string(header.TransactionID()) != ComputeTransactionID(testNonce(), creator) {
+		t.Errorf("transaction ID %s is not computed from the nonce and creator", header.TransactionID())
+	}
+
+	random, err := newTransactionHeader("mychannel", nil, creator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(random.Nonce()) != NonceSize || random.TransactionID() == header.TransactionID() {
+		t.Errorf("expected a random nonce of %d bytes, got %x", NonceSize, random.Nonce())
+	}
+
+	if _, err := newTransactionHeader("mychannel", testNonce(), nil); err == nil {
+		t.Error("expected an error without creator")
+	}
+}
+
+func TestCheckCreator(t *testing.T) {
+	tests := []struct {
+		name           string
+		creator        string
+		signingCreator string
+		err            bool
+	}{
+		{name: "creator not set", signingCreator: "org1"},
+		{name: "matching creator", creator: "org1", signingCreator: "org1"},
+		{name: "other creator", creator: "org2", signingCreator: "org1", err: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := checkCreator([]byte(test.creator), []byte(test.signingCreator)); (err != nil) != test.err {
+				t.Errorf("unexpected error %v", err)
+			}
+		})
+	}
+}
@@ -1,13 +1,15 @@
 package chaincode
 
 import (
-	"fmt"
 	"strings"
+	"time"
 
 	"dendrix.io/fabricsdk/providers"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/common/cauthdsl"
 	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
 	"github.com/pkg/errors"
 )
 
@@ -22,21 +24,51 @@ type upgradeChaincodeClient struct {
 	policy           string
 	args             [][]byte
 	collConfig       []*common.CollectionConfig
+	targets          []fab.Peer
+	timeout          time.Duration
+	metadata         *MetadataCache
 }
 
 //NewUpgradeClient returns a ChaincodeClient implementation for upgrading a chaincode on the client org anchor peer
 func NewUpgradeClient(provider providers.FabricNetworkClientProvider, channelID string, chaincodeID string, chaincodeVersion string, chaincodePath string, policy string, args [][]byte, collectionConfigFile string) (ChaincodeClient, error) {
+	req := DeployRequest{
+		ChannelID:        channelID,
+		ChaincodeID:      chaincodeID,
+		ChaincodeVersion: chaincodeVersion,
+		ChaincodePath:    chaincodePath,
+		Args:             args,
+	}
+	return NewUpgradeClientFromRequest(provider, req, WithPolicy(policy), WithCollectionConfigFile(collectionConfigFile))
+}
+
+//NewUpgradeClientFromRequest returns a ChaincodeClient implementation for upgrading the requested chaincode.
+//Unless targets are set in the options, the chaincode is upgraded on the client org anchor peer.
+func NewUpgradeClientFromRequest(provider providers.FabricNetworkClientProvider, req DeployRequest, opts ...Option) (ChaincodeClient, error) {
+	if provider == nil {
+		return nil, errors.Errorf("Fabric network client provider is not set.")
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	options, err := NewOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := options.Validate(UpgradeRequestType); err != nil {
+		return nil, err
+	}
 	i := new(upgradeChaincodeClient)
 	i.FabricNetworkClientProvider = provider
-	i.channelID = channelID
-	i.chaincodeID = chaincodeID
-	i.chaincodeVersion = chaincodeVersion
-	i.chaincodePath = chaincodePath
-	i.policy = policy
-	i.args = args
-	// Private Data Collection Configuration
-	// - see fixtures/config/pvtdatacollection.json for sample config file
-	collCfg, err := collectionConfig(collectionConfigFile)
+	i.channelID = req.ChannelID
+	i.chaincodeID = req.ChaincodeID
+	i.chaincodeVersion = req.ChaincodeVersion
+	i.chaincodePath = req.ChaincodePath
+	i.policy = options.Policy
+	i.args = req.Args
+	i.targets = options.Targets
+	i.timeout = options.Timeout
+	i.metadata = options.MetadataCache
+	collCfg, err := options.collectionConfig()
 	if err != nil {
 		return nil, err
 	}
@@ -45,16 +77,23 @@ func NewUpgradeClient(provider providers.FabricNetworkClientProvider, channelID
 }
 
 func (ic upgradeChaincodeClient) Invoke() ([]byte, error) {
-	resMgmtClient, err := ic.ResourceMgmtClientByAdmin()
+	result, err := ic.InvokeResult()
 	if err != nil {
-		return []byte("0x00"), err
+		return nil, err
 	}
-	ccIDVersion := ic.chaincodeID + "." + ic.chaincodeVersion
-	fmt.Printf("\n Sending upgrade %s ...\n", ccIDVersion)
+	return result.Payload, nil
+}
 
+//InvokeResult upgrades the chaincode and returns the deployment transaction result.
+//The result info is EXISTS if the chaincode was already instantiated.
+func (ic upgradeChaincodeClient) InvokeResult() (*Result, error) {
+	resMgmtClient, err := ic.ResourceMgmtClientByAdmin()
+	if err != nil {
+		return nil, err
+	}
 	chaincodePolicy, err := ic.newChaincodePolicy()
 	if err != nil {
-		return []byte("0x00"), err
+		return nil, err
 	}
 
 	req := resmgmt.UpgradeCCRequest{
@@ -66,19 +105,29 @@ func (ic upgradeChaincodeClient) Invoke() ([]byte, error) {
 		CollConfig: ic.collConfig,
 	}
 
-	_, err = resMgmtClient.UpgradeCC(ic.channelID, req, resmgmt.WithTargets(ic.ClientOrgPeers()[0]))
+	targets := ic.targets
+	if len(targets) == 0 {
+		peers := ic.ClientOrgPeers()
+		if len(peers) == 0 {
+			return nil, errNoPeersFound(ic.ClientOrgID())
+		}
+		targets = peers[:1]
+	}
+	response, err := resMgmtClient.UpgradeCC(ic.channelID, req, resmgmtRequestOptions(targets, ic.timeout)...)
 	if err != nil {
 		if strings.Contains(err.Error(), "chaincode exists "+ic.chaincodeID) {
 			// Ignore
 			//cliconfig.Config().Logger().Infof("Chaincode %s already instantiated.", cliconfig.Config().ChaincodeID())
-			fmt.Printf("...chaincode %s already instantiated.\n", ic.chaincodeID)
-			return []byte("EXISTS"), nil
+			return deployResult(InfoExists, "", pb.TxValidationCode_NOT_VALIDATED, targets), nil
 		}
 		return nil, errors.Errorf("error upgrading chaincode: %v", err)
 	}
 
-	fmt.Printf("...successfuly upgraded chaincode %s on channel %s.\n", ic.chaincodeID, ic.channelID)
-	return []byte("OK"), nil
+	//The metadata of the previous version is no longer valid
+	if ic.metadata != nil {
+		ic.metadata.Invalidate(ic.channelID, ic.chaincodeID)
+	}
+	return deployResult(InfoOK, string(response.TransactionID), pb.TxValidationCode_VALID, targets), nil
 }
 
 func (ic upgradeChaincodeClient) Terminate() {
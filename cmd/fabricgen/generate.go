@@ -0,0 +1,388 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"dendrix.io/fabricsdk/chaincode"
+	"github.com/pkg/errors"
+)
+
+//generator emits the typed Go client of the contracts of a chaincode
+type generator struct {
+	metadata    *chaincode.ChaincodeMetadata
+	packageName string
+	//typeNames are the Go types of the component schemas generated as structs, keyed by schema name
+	typeNames map[string]string
+	//resolving are the schemas whose references are being inlined, to detect the schemas referencing themselves
+	resolving map[string]bool
+}
+
+type contractTemplate struct {
+	Name         string
+	TypeName     string
+	Transactions []transactionTemplate
+}
+
+type transactionTemplate struct {
+	Name          string
+	QualifiedName string
+	MethodName    string
+	Submit        bool
+	Params        []paramTemplate
+	ReturnType    string
+}
+
+type paramTemplate struct {
+	Name string
+	Type string
+}
+
+type structTemplate struct {
+	Name   string
+	Fields []fieldTemplate
+}
+
+type fieldTemplate struct {
+	Name string
+	Type string
+	Tag  string
+}
+
+var clientTemplate = template.Must(template.New("client").Funcs(template.FuncMap{"zero": zeroValue}).Parse(`// Code generated by fabricgen from the chaincode metadata. DO NOT EDIT.
+
+//Package {{.Package}} is the typed client of the chaincode contracts
+package {{.Package}}
+
+import (
+	"dendrix.io/fabricsdk/chaincode"
+)
+{{range .Structs}}
+//{{.Name}} is a component schema of the chaincode
+type {{.Name}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}} ` + "`{{.Tag}}`" + `
+{{- end}}
+}
+{{end}}
+{{- range .Contracts}}
+//{{.TypeName}} is the typed client of the {{.Name}} contract
+type {{.TypeName}} struct {
+	contract *chaincode.Contract
+}
+
+//New{{.TypeName}} returns the typed client of the {{.Name}} contract of the chaincode of the contract handle
+func New{{.TypeName}}(contract *chaincode.Contract) *{{.TypeName}} {
+	return &{{.TypeName}}{contract: contract}
+}
+{{$client := .TypeName}}
+{{- range .Transactions}}
+//{{.MethodName}} {{if .Submit}}submits{{else}}evaluates{{end}} the {{.Name}} transaction
+func (c *{{$client}}) {{.MethodName}}({{range $i, $p := .Params}}{{if $i}}, {{end}}{{$p.Name}} {{$p.Type}}{{end}}) ({{if .ReturnType}}{{.ReturnType}}, {{end}}error) {
+	args, err := chaincode.MarshalArgs(chaincode.ContractAPICodec{{range .Params}}, {{.Name}}{{end}})
+	if err != nil {
+		return {{if .ReturnType}}{{.ReturnType | zero}}, {{end}}err
+	}
+	{{if .ReturnType}}r, err := {{else}}_, err = {{end}}c.contract.{{if .Submit}}Submit{{else}}Evaluate{{end}}("{{.QualifiedName}}", args...)
+	if err != nil {
+		return {{if .ReturnType}}{{.ReturnType | zero}}, {{end}}err
+	}
+{{- if .ReturnType}}
+	var result {{.ReturnType}}
+	if err := chaincode.UnmarshalPayload(chaincode.ContractAPICodec, r.Payload, &result); err != nil {
+		return {{.ReturnType | zero}}, err
+	}
+	return result, nil
+{{- else}}
+	return nil
+{{- end}}
+}
+{{end}}
+{{- end}}
+`))
+
+//generate returns the formatted source of the client package
+func (g *generator) generate() ([]byte, error) {
+	//The contract clients are declared first so that they keep their names whatever the schemas
+	pkg := make(scope)
+	contractTypes := make(map[string]string)
+	for _, name := range g.contractNames() {
+		contractTypes[name] = pkg.declare(exportedName(g.metadata.Contracts[name].Name)+"Client", constructorName)
+	}
+	g.typeNames = make(map[string]string)
+	g.resolving = make(map[string]bool)
+	for _, name := range g.schemaNames() {
+		if isStruct(g.metadata.Components.Schemas[name]) {
+			g.typeNames[name] = pkg.declare(exportedName(name))
+		}
+	}
+	structs, err := g.structs()
+	if err != nil {
+		return nil, err
+	}
+	contracts, err := g.contracts(contractTypes)
+	if err != nil {
+		return nil, err
+	}
+	var src bytes.Buffer
+	data := map[string]interface{}{
+		"Package":   g.packageName,
+		"Structs":   structs,
+		"Contracts": contracts,
+	}
+	if err := clientTemplate.Execute(&src, data); err != nil {
+		return nil, errors.Wrap(err, "failed to generate client")
+	}
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to format generated client:\n%s", src.String())
+	}
+	return formatted, nil
+}
+
+func (g *generator) schemaNames() []string {
+	var names []string
+	for name := range g.metadata.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (g *generator) contractNames() []string {
+	var names []string
+	for name := range g.metadata.Contracts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//structs returns the structs of the component schemas that are objects, the other schemas being inlined where referenced
+func (g *generator) structs() ([]structTemplate, error) {
+	var structs []structTemplate
+	for _, name := range g.schemaNames() {
+		schema := g.metadata.Components.Schemas[name]
+		if !isStruct(schema) {
+			continue
+		}
+		required := make(map[string]bool)
+		for _, property := range schema.Required {
+			required[property] = true
+		}
+		var properties []string
+		for property := range schema.Properties {
+			properties = append(properties, property)
+		}
+		sort.Strings(properties)
+		s := structTemplate{Name: g.typeNames[name]}
+		fields := make(scope)
+		for _, property := range properties {
+			fieldType, err := g.goType(schema.Properties[property])
+			if err != nil {
+				return nil, errors.WithMessage(err, fmt.Sprintf("property %s of schema %s", property, name))
+			}
+			tag := `json:"` + property
+			if !required[property] {
+				tag += ",omitempty"
+			}
+			tag += `"`
+			s.Fields = append(s.Fields, fieldTemplate{Name: fields.declare(exportedName(property)), Type: fieldType, Tag: tag})
+		}
+		structs = append(structs, s)
+	}
+	return structs, nil
+}
+
+//contracts returns the clients of the contracts, whose types are keyed by contract name
+func (g *generator) contracts(contractTypes map[string]string) ([]contractTemplate, error) {
+	var contracts []contractTemplate
+	for _, name := range g.contractNames() {
+		contract := g.metadata.Contracts[name]
+		c := contractTemplate{Name: contract.Name, TypeName: contractTypes[name]}
+		methods := make(scope)
+		for _, tx := range contract.Transactions {
+			t := transactionTemplate{
+				Name:          tx.Name,
+				QualifiedName: tx.QualifiedName(contract.Name),
+				MethodName:    methods.declare(exportedName(tx.Name)),
+				Submit:        tx.IsSubmit(),
+			}
+			params := make(scope)
+			for name := range reservedNames {
+				params[name] = true
+			}
+			for _, param := range tx.Parameters {
+				paramType, err := g.goType(param.Schema)
+				if err != nil {
+					return nil, errors.WithMessage(err, fmt.Sprintf("parameter %s of transaction %s", param.Name, t.QualifiedName))
+				}
+				t.Params = append(t.Params, paramTemplate{Name: params.declare(paramName(param.Name)), Type: paramType})
+			}
+			if tx.Returns != nil {
+				returnType, err := g.goType(tx.Returns)
+				if err != nil {
+					return nil, errors.WithMessage(err, fmt.Sprintf("returned value of transaction %s", t.QualifiedName))
+				}
+				t.ReturnType = returnType
+			}
+			c.Transactions = append(c.Transactions, t)
+		}
+		contracts = append(contracts, c)
+	}
+	return contracts, nil
+}
+
+//goType returns the Go type of the schema
+func (g *generator) goType(schema *chaincode.Schema) (string, error) {
+	if schema == nil {
+		return "interface{}", nil
+	}
+	if schema.Ref != "" {
+		resolved, err := g.metadata.Resolve(schema)
+		if err != nil {
+			return "", err
+		}
+		name := chaincode.SchemaRefName(schema.Ref)
+		if typeName, found := g.typeNames[name]; found {
+			return typeName, nil
+		}
+		//The schemas that are not generated as structs, e.g. string enums or arrays, are inlined
+		if g.resolving[name] {
+			return "", errors.Errorf("schema %s references itself", schema.Ref)
+		}
+		g.resolving[name] = true
+		defer delete(g.resolving, name)
+		return g.goType(resolved)
+	}
+	switch schema.Type {
+	case "string":
+		return "string", nil
+	case "boolean":
+		return "bool", nil
+	case "integer":
+		switch schema.Format {
+		case "int32":
+			return "int32", nil
+		case "int64":
+			return "int64", nil
+		}
+		return "int", nil
+	case "number":
+		if schema.Format == "float" {
+			return "float32", nil
+		}
+		return "float64", nil
+	case "array":
+		itemType, err := g.goType(schema.Items)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + itemType, nil
+	case "object":
+		if len(schema.Properties) == 0 {
+			return "map[string]interface{}", nil
+		}
+	}
+	return "interface{}", nil
+}
+
+//isStruct reports whether the component schema is generated as a struct, as are the objects with properties
+func isStruct(schema *chaincode.Schema) bool {
+	return schema != nil && schema.Ref == "" && (schema.Type == "object" || schema.Type == "") && len(schema.Properties) > 0
+}
+
+func zeroValue(goType string) string {
+	switch {
+	case goType == "string":
+		return `""`
+	case goType == "bool":
+		return "false"
+	case strings.HasPrefix(goType, "int") || strings.HasPrefix(goType, "float"):
+		return "0"
+	case strings.HasPrefix(goType, "[]") || strings.HasPrefix(goType, "map[") || goType == "interface{}":
+		return "nil"
+	}
+	return goType + "{}"
+}
+
+//exportedName returns the name as an exported Go identifier, e.g. AssetId for asset-id
+func exportedName(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	exported := b.String()
+	if exported == "" || unicode.IsDigit([]rune(exported)[0]) {
+		exported = "X" + exported
+	}
+	return exported
+}
+
+//scope holds the identifiers declared in a scope of the generated source. Different names may give the same identifier,
+//e.g. GetAsset for get-asset and getAsset: an identifier colliding with one declared before it gets a numeric suffix.
+type scope map[string]bool
+
+//declare declares the identifier, suffixed if it or one of its companions is already declared in the scope.
+//The companions derive the identifiers declared along with it, e.g. the constructor of a type.
+func (s scope) declare(identifier string, companions ...func(string) string) string {
+	for i := 1; ; i++ {
+		candidate := identifier
+		if i > 1 {
+			candidate = fmt.Sprintf("%s%d", identifier, i)
+		}
+		names := []string{candidate}
+		for _, companion := range companions {
+			names = append(names, companion(candidate))
+		}
+		if !s.declaredAny(names) {
+			for _, name := range names {
+				s[name] = true
+			}
+			return candidate
+		}
+	}
+}
+
+func (s scope) declaredAny(names []string) bool {
+	for _, name := range names {
+		if s[name] {
+			return true
+		}
+	}
+	return false
+}
+
+//constructorName returns the name of the constructor of the contract client type
+func constructorName(typeName string) string {
+	return "New" + typeName
+}
+
+//reservedNames are the identifiers used by the generated methods
+var reservedNames = map[string]bool{"c": true, "args": true, "err": true, "r": true, "result": true, "chaincode": true}
+
+//paramName returns the name as an unexported Go identifier that is not a keyword
+func paramName(name string) string {
+	exported := []rune(exportedName(name))
+	exported[0] = unicode.ToLower(exported[0])
+	param := string(exported)
+	if token.Lookup(param).IsKeyword() || reservedNames[param] {
+		param += "Arg"
+	}
+	return param
+}
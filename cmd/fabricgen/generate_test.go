@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"dendrix.io/fabricsdk/chaincode"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestGenerateGolden(t *testing.T) {
+	raw, err := ioutil.ReadFile(filepath.Join("testdata", "metadata.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	metadata, err := chaincode.ParseMetadata(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &generator{metadata: metadata, packageName: "assets"}
+	src, err := g.generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := filepath.Join("testdata", "client.golden")
+	if *update {
+		if err := ioutil.WriteFile(golden, src, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, want) {
+		t.Errorf("generated client differs from %s, run go test -update to regenerate it:\n%s", golden, src)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata string
+		err      string
+	}{
+		{
+			name:     "undefined reference",
+			metadata: `{"contracts":{"A":{"name":"A","transactions":[{"name":"Get","returns":{"$ref":"#/components/schemas/Missing"}}]}}}`,
+			err:      "Missing",
+		},
+		{
+			name: "schema referencing itself",
+			metadata: `{"contracts":{"A":{"name":"A","transactions":[{"name":"Get","returns":{"$ref":"#/components/schemas/Loop"}}]}},
+				"components":{"schemas":{"Loop":{"type":"array","items":{"$ref":"#/components/schemas/Loop"}}}}}`,
+			err: "references itself",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			metadata, err := chaincode.ParseMetadata([]byte(test.metadata))
+			if err != nil {
+				t.Fatal(err)
+			}
+			g := &generator{metadata: metadata, packageName: "client"}
+			if _, err := g.generate(); err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("expected error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestScopeDeclare(t *testing.T) {
+	s := make(scope)
+	tests := []struct {
+		identifier string
+		companions []func(string) string
+		want       string
+	}{
+		{identifier: "AssetClient", companions: []func(string) string{constructorName}, want: "AssetClient"},
+		{identifier: "AssetClient", want: "AssetClient2"},
+		{identifier: "NewAssetClient2", want: "NewAssetClient2"},
+		{identifier: "AssetClient", companions: []func(string) string{constructorName}, want: "AssetClient3"},
+		{identifier: "Asset", want: "Asset"},
+	}
+	for _, test := range tests {
+		if got := s.declare(test.identifier, test.companions...); got != test.want {
+			t.Errorf("declare(%s) = %s, want %s", test.identifier, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+//Command fabricgen generates the typed Go client of a contract API chaincode from its metadata,
+//read from a file or fetched from the GetMetadata function of the chaincode
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"dendrix.io/fabricsdk"
+	"dendrix.io/fabricsdk/chaincode"
+)
+
+func main() {
+	metadataFile := flag.String("metadata", "", "chaincode metadata file, fetched from the chaincode if not set")
+	configPath := flag.String("config", ".", "directory containing the fabricApp.json, to fetch the metadata")
+	clientOrgID := flag.String("org", "", "client org fetching the metadata")
+	channelID := flag.String("channel", "", "channel of the chaincode, to fetch the metadata")
+	chaincodeID := flag.String("chaincode", "", "chaincode, to fetch the metadata")
+	packageName := flag.String("package", "client", "package name of the generated client")
+	out := flag.String("out", "", "generated file, standard output if not set")
+	flag.Parse()
+
+	raw, err := readMetadata(*metadataFile, *configPath, *clientOrgID, *channelID, *chaincodeID)
+	if err != nil {
+		fail(err)
+	}
+	metadata, err := chaincode.ParseMetadata(raw)
+	if err != nil {
+		fail(err)
+	}
+	g := &generator{metadata: metadata, packageName: *packageName}
+	src, err := g.generate()
+	if err != nil {
+		fail(err)
+	}
+	if *out == "" {
+		fmt.Print(string(src))
+		return
+	}
+	if err := ioutil.WriteFile(*out, src, 0644); err != nil {
+		fail(err)
+	}
+}
+
+func readMetadata(metadataFile string, configPath string, clientOrgID string, channelID string, chaincodeID string) ([]byte, error) {
+	if metadataFile != "" {
+		return ioutil.ReadFile(metadataFile)
+	}
+	if clientOrgID == "" || channelID == "" || chaincodeID == "" {
+		return nil, fmt.Errorf("-org, -channel and -chaincode are required to fetch the metadata")
+	}
+	fN, err := fabricsdk.NewFabricNetwork(configPath)
+	if err != nil {
+		return nil, err
+	}
+	defer fN.Close()
+	req := chaincode.InvokeRequest{ChannelID: channelID, ChaincodeID: chaincodeID, Fcn: chaincode.MetadataFunction}
+	client, err := fN.Query(clientOrgID, req)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Terminate()
+	return client.Invoke()
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -0,0 +1,32 @@
+//Command fabricvalidate runs the preflight validation of a fabricApp.json and of the connection profiles it references
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"dendrix.io/fabricsdk"
+)
+
+func main() {
+	configPath := flag.String("config", ".", "directory containing the fabricApp.json")
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	flag.Parse()
+
+	report := fabricsdk.Validate(*configPath)
+	if *asJSON {
+		out, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		fmt.Println(string(out))
+	} else {
+		fmt.Println(report.String())
+	}
+	if !report.OK() {
+		os.Exit(1)
+	}
+}
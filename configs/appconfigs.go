@@ -1,7 +1,13 @@
 package configs
 
 import (
-	"fmt"
+	"bytes"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/core"
 
 	"github.com/spf13/viper"
 )
@@ -17,10 +23,28 @@ const (
 	// policySigners     = "policysigners"
 	// policySignerRole  = "policysignerrole"
 	// policy            = "policy"
-	clientOrgs = "clientorgs"
-	orgID      = "orgid"
+	clientOrgs        = "clientorgs"
+	orgID             = "orgid"
+	admins            = "admins"
+	networkConfigKey  = "networkconfig"
+	networkConfigType = "networkconfigtype"
 )
 
+//clientOrgKeys lists the keys allowed in a client org config
+var clientOrgKeys = map[string]bool{
+	orgID:             true,
+	username:          true,
+	admins:            true,
+	networkConfigPath: true,
+	networkConfigKey:  true,
+	networkConfigType: true,
+}
+
+//DefaultEnvPrefix is the prefix of the environment variables overriding the app config keys
+const DefaultEnvPrefix = "FABRICSDK"
+
+const defaultNetworkConfigType = "yaml"
+
 /* const (
 	POLICYTYPE_OR  = "ANY"
 	POLICYTYPE_AND = "SPECIFIC"
@@ -28,68 +52,231 @@ const (
 
 const appConfigFile = "fabricApp.json"
 
-type appConfig map[string]interface{}
-
-/* func (cfg appConfig) getChaincodePath() string {
-	return cfg[chaincodePath].(string)
+//AppConfig is the Go equivalent of the fabricApp.json app config
+type AppConfig struct {
+	ClientOrgs []ClientOrgConfig
 }
 
-func (cfg appConfig) getChaincodeVersion() string {
-	return cfg[chaincodeVersion].(string)
+//ClientOrgConfig is the app config of a client org.
+//Either NetworkConfigPath or the connection profile content in NetworkConfig must be set.
+//User is loaded from the keystore with the admins of the client org. Without it no identity is loaded,
+//the client org then being only usable by offline clients signing with external signers.
+type ClientOrgConfig struct {
+	//Name of the client org config, as listed in clientorgs
+	Name              string
+	OrgID             string
+	User              string
+	Admins            map[string][]string
+	NetworkConfigPath string
+	NetworkConfig     []byte
+	//NetworkConfigType is the format of NetworkConfig, yaml by default
+	NetworkConfigType string
 }
 
-func (cfg appConfig) getChaincodeID() string {
-	return cfg[chaincodeID].(string)
-} */
-
-func (cfg appConfig) getUser() string {
-	return cfg[username].(string)
+//adminsByOrg returns the configured admin names keyed by the lower case org ID
+func (cfg *ClientOrgConfig) adminsByOrg() map[string][]string {
+	adminsByOrg := make(map[string][]string)
+	for org, names := range cfg.Admins {
+		adminsByOrg[strings.ToLower(org)] = names
+	}
+	return adminsByOrg
 }
 
-func (cfg appConfig) getNetworkConfigPath() string {
-	return cfg[networkConfigPath].(string)
+//networkConfigProvider returns the connection profile provider of the org.
+//An inline connection profile takes precedence over the connection profile file path.
+func (cfg *ClientOrgConfig) networkConfigProvider() core.ConfigProvider {
+	if len(cfg.NetworkConfig) > 0 {
+		cfgType := cfg.NetworkConfigType
+		if cfgType == "" {
+			cfgType = defaultNetworkConfigType
+		}
+		return config.FromRaw(cfg.NetworkConfig, cfgType)
+	}
+	return config.FromFile(cfg.NetworkConfigPath)
 }
 
-func (cfg appConfig) getOrgID() string {
-	return cfg[orgID].(string)
+//toMap returns the app config in the layout of the fabricApp.json
+func (cfg AppConfig) toMap() map[string]interface{} {
+	cfgMap := make(map[string]interface{})
+	var orgs []interface{}
+	for _, org := range cfg.ClientOrgs {
+		orgs = append(orgs, org.Name)
+		orgMap := make(map[string]interface{})
+		setIfNotEmpty(orgMap, orgID, org.OrgID)
+		setIfNotEmpty(orgMap, username, org.User)
+		setIfNotEmpty(orgMap, networkConfigPath, org.NetworkConfigPath)
+		setIfNotEmpty(orgMap, networkConfigKey, string(org.NetworkConfig))
+		setIfNotEmpty(orgMap, networkConfigType, org.NetworkConfigType)
+		if len(org.Admins) > 0 {
+			adminsByOrg := make(map[string]interface{})
+			for adminOrg, names := range org.Admins {
+				var adminNames []interface{}
+				for _, name := range names {
+					adminNames = append(adminNames, name)
+				}
+				adminsByOrg[adminOrg] = adminNames
+			}
+			orgMap[admins] = adminsByOrg
+		}
+		cfgMap[org.Name] = orgMap
+	}
+	cfgMap[clientOrgs] = orgs
+	return cfgMap
 }
 
-/* func (cfg appConfig) getPolicyType() string {
-	return cfg[policyType].(string)
+func setIfNotEmpty(cfgMap map[string]interface{}, key string, value string) {
+	if value != "" {
+		cfgMap[key] = value
+	}
 }
 
-func (cfg appConfig) getPolicySigners() []string {
-	return cfg[policySigners].([]string)
+//appConfigSource loads the raw app config into the viper instance
+type appConfigSource func(v *viper.Viper) error
+
+func appConfigFromPath(appConfigPath string) appConfigSource {
+	return func(v *viper.Viper) error {
+		v.SetConfigFile(filepath.Join(appConfigPath, appConfigFile))
+		return v.ReadInConfig()
+	}
 }
 
-func (cfg appConfig) getPolicySignerRole() string {
-	return cfg[policySignerRole].(string)
+func appConfigFromBytes(raw []byte, configType string) appConfigSource {
+	return func(v *viper.Viper) error {
+		v.SetConfigType(configType)
+		return v.ReadConfig(bytes.NewReader(raw))
+	}
 }
 
-func (cfg appConfig) getPolicy() string {
-	return cfg[policy].(string)
-} */
+func appConfigFromMap(cfg map[string]interface{}) appConfigSource {
+	return func(v *viper.Viper) error {
+		return v.MergeConfigMap(cfg)
+	}
+}
 
-//getAppConfig creates and initializes an instance of appConfig by loading the fabricApp.json config
-func initAppConfig(appConfigPath string) (map[string]*appConfig, error) {
+//initAppConfig loads the config source and decodes and validates the app config of each client org.
+//Every key can be overridden by an environment variable named after the key path with the env prefix, e.g. FABRICSDK_ORG1_USER.
+func initAppConfig(source appConfigSource, envPrefix string) (*AppConfig, error) {
 	v := viper.New()
-	// v.SetEnvPrefix(envPrefix)
-	// v.BindEnv(configEnvVar)
-	v.AddConfigPath(appConfigPath)
-	v.SetConfigFile(appConfigFile)
-	if err := v.ReadInConfig(); err != nil {
+	v.SetEnvPrefix(envPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	if err := source(v); err != nil {
 		return nil, err
 	}
-	var configMap = make(map[string]*appConfig)
-	clientorgs := v.Get(clientOrgs).([]interface{})
-	for _, o := range clientorgs {
-		org := o.(string)
-		fmt.Println(org)
-		val := v.Get(org)
-		if val != nil {
-			c := appConfig(val.(map[string]interface{}))
-			configMap[org] = &c
+	return decodeAppConfig(v)
+}
+
+//decodeAppConfig decodes the app config, reporting every unknown key, type mismatch and missing value
+func decodeAppConfig(v *viper.Viper) (*AppConfig, error) {
+	problems := new(ValidationError)
+	appCfg := new(AppConfig)
+	orgs := make(map[string]bool)
+	for _, org := range stringList(v, clientOrgs, problems) {
+		orgs[strings.ToLower(org)] = true
+		if !isOrgSet(v, org) {
+			problems.add(org, "client org config is missing")
+			continue
+		}
+		orgCfg := ClientOrgConfig{Name: org}
+		orgCfg.OrgID = stringValue(v, org+"."+orgID, problems)
+		orgCfg.User = stringValue(v, org+"."+username, problems)
+		orgCfg.NetworkConfigPath = stringValue(v, org+"."+networkConfigPath, problems)
+		orgCfg.NetworkConfig = []byte(stringValue(v, org+"."+networkConfigKey, problems))
+		orgCfg.NetworkConfigType = stringValue(v, org+"."+networkConfigType, problems)
+		orgCfg.Admins = adminsValue(v, org+"."+admins, problems)
+		appCfg.ClientOrgs = append(appCfg.ClientOrgs, orgCfg)
+	}
+	unknown := make(map[string]bool)
+	for _, key := range v.AllKeys() {
+		parts := strings.SplitN(key, ".", 3)
+		switch {
+		case parts[0] == clientOrgs:
+		case !orgs[parts[0]]:
+			unknown[parts[0]] = true
+		case len(parts) > 1 && !clientOrgKeys[parts[1]]:
+			unknown[parts[0]+"."+parts[1]] = true
+		}
+	}
+	for key := range unknown {
+		problems.add(key, "unknown key")
+	}
+	problems.merge(ValidateAppConfig(*appCfg))
+	if err := problems.errorOrNil(); err != nil {
+		return nil, err
+	}
+	return appCfg, nil
+}
+
+//isOrgSet reports whether any key of the client org is set in the config or the environment
+func isOrgSet(v *viper.Viper, org string) bool {
+	for key := range clientOrgKeys {
+		if v.IsSet(org + "." + key) {
+			return true
+		}
+	}
+	return false
+}
+
+func stringValue(v *viper.Viper, key string, problems *ValidationError) string {
+	switch val := v.Get(key).(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	default:
+		problems.add(key, "must be a string")
+		return ""
+	}
+}
+
+func stringList(v *viper.Viper, key string, problems *ValidationError) []string {
+	switch val := v.Get(key).(type) {
+	case nil:
+		return nil
+	case string:
+		//Lists overridden by environment variables are space separated
+		return strings.Fields(val)
+	case []string:
+		return val
+	case []interface{}:
+		var list []string
+		for _, item := range val {
+			s, ok := item.(string)
+			if !ok || s == "" {
+				problems.add(key, "must be a list of non empty strings")
+				return nil
+			}
+			list = append(list, s)
+		}
+		return list
+	default:
+		problems.add(key, "must be a list of strings")
+		return nil
+	}
+}
+
+//adminsValue decodes the admins of each org. An org may be mapped to a single admin name or to a list of admin names.
+func adminsValue(v *viper.Viper, key string, problems *ValidationError) map[string][]string {
+	val := v.Get(key)
+	if val == nil {
+		return nil
+	}
+	orgsMap, ok := val.(map[string]interface{})
+	if !ok {
+		problems.add(key, "must map org IDs to admin names")
+		return nil
+	}
+	adminsByOrg := make(map[string][]string)
+	adminOrgs := make([]string, 0, len(orgsMap))
+	for adminOrg := range orgsMap {
+		adminOrgs = append(adminOrgs, adminOrg)
+	}
+	sort.Strings(adminOrgs)
+	for _, adminOrg := range adminOrgs {
+		names := stringList(v, key+"."+adminOrg, problems)
+		if len(names) > 0 {
+			adminsByOrg[adminOrg] = names
 		}
 	}
-	return configMap, nil
+	return adminsByOrg
 }
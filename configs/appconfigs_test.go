@@ -0,0 +1,131 @@
+package configs
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func problemFields(t *testing.T, err error) []string {
+	if err == nil {
+		return nil
+	}
+	validationErr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("expected a *ValidationError, got %T: %v", err, err)
+	}
+	var fields []string
+	for _, problem := range validationErr.Problems {
+		fields = append(fields, problem.Field)
+	}
+	return fields
+}
+
+func TestDecodeAppConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		problems []string
+		admins   map[string][]string
+	}{
+		{
+			name:   "admins as a string or a list",
+			raw:    `{"clientorgs":["org1"],"org1":{"orgid":"Org1MSP","user":"User1","networkconfigpath":"org1.yaml","admins":{"Org1MSP":"Admin","Org2MSP":["Admin","Admin2"]}}}`,
+			admins: map[string][]string{"org1msp": {"Admin"}, "org2msp": {"Admin", "Admin2"}},
+		},
+		{
+			name:     "unknown keys",
+			raw:      `{"clientorgs":["org1"],"org1":{"networkconfigpath":"org1.yaml","user":"User1","usr":"User1"},"org3":{"user":"User1"}}`,
+			problems: []string{"org1.usr", "org3"},
+		},
+		{
+			name:     "value of the wrong type",
+			raw:      `{"clientorgs":["org1"],"org1":{"orgid":["Org1MSP"],"user":"User1","networkconfigpath":"org1.yaml"}}`,
+			problems: []string{"org1.orgid"},
+		},
+		{
+			name:     "empty admin name in a list",
+			raw:      `{"clientorgs":["org1"],"org1":{"user":"User1","networkconfigpath":"org1.yaml","admins":{"Org1MSP":["Admin",""]}}}`,
+			problems: []string{"org1.admins.org1msp"},
+		},
+		{
+			name:     "admins not keyed by org",
+			raw:      `{"clientorgs":["org1"],"org1":{"user":"User1","networkconfigpath":"org1.yaml","admins":"Admin"}}`,
+			problems: []string{"org1.admins"},
+		},
+		{
+			name:     "missing client org config",
+			raw:      `{"clientorgs":["org1","org2"],"org1":{"user":"User1","networkconfigpath":"org1.yaml"}}`,
+			problems: []string{"org2"},
+		},
+		{
+			name:     "missing connection profile",
+			raw:      `{"clientorgs":["org1"],"org1":{"user":"User1"}}`,
+			problems: []string{"org1.networkconfigpath"},
+		},
+		{
+			name:     "no client org",
+			raw:      `{}`,
+			problems: []string{"clientorgs"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := make(map[string]interface{})
+			if err := json.Unmarshal([]byte(test.raw), &cfg); err != nil {
+				t.Fatal(err)
+			}
+			appCfg, err := initAppConfig(appConfigFromMap(cfg), "FABRICSDK_TEST")
+			if fields := problemFields(t, err); !reflect.DeepEqual(fields, test.problems) {
+				t.Fatalf("expected problems %v, got %v", test.problems, fields)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(appCfg.ClientOrgs[0].Admins, test.admins) {
+				t.Errorf("expected admins %v, got %v", test.admins, appCfg.ClientOrgs[0].Admins)
+			}
+		})
+	}
+}
+
+func TestValidateAppConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		appCfg   AppConfig
+		problems []string
+	}{
+		{
+			name:   "valid",
+			appCfg: AppConfig{ClientOrgs: []ClientOrgConfig{{Name: "org1", User: "User1", NetworkConfig: []byte("name: net")}}},
+		},
+		{
+			name:     "no client org",
+			appCfg:   AppConfig{},
+			problems: []string{"clientorgs"},
+		},
+		{
+			name: "unnamed and duplicate client orgs",
+			appCfg: AppConfig{ClientOrgs: []ClientOrgConfig{
+				{Name: "org1", User: "User1", NetworkConfigPath: "org1.yaml"},
+				{User: "User1", NetworkConfigPath: "org2.yaml"},
+				{Name: "Org1", User: "User1", NetworkConfigPath: "org1.yaml"},
+			}},
+			problems: []string{"clientorgs[1]", "clientorgs[2]"},
+		},
+		{
+			name: "empty admin name",
+			appCfg: AppConfig{ClientOrgs: []ClientOrgConfig{
+				{Name: "org1", User: "User1", NetworkConfigPath: "org1.yaml", Admins: map[string][]string{"Org1MSP": {"Admin", ""}}},
+			}},
+			problems: []string{"org1.admins.Org1MSP[1]"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if fields := problemFields(t, ValidateAppConfig(test.appCfg)); !reflect.DeepEqual(fields, test.problems) {
+				t.Errorf("expected problems %v, got %v", test.problems, fields)
+			}
+		})
+	}
+}
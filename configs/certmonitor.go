@@ -0,0 +1,201 @@
+package configs
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"sort"
+	"sync"
+	"time"
+
+	mspapi "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
+	"github.com/pkg/errors"
+)
+
+const defaultCertMonitorInterval = time.Hour
+
+var defaultCertExpiryThresholds = []time.Duration{30 * 24 * time.Hour, 7 * 24 * time.Hour, 24 * time.Hour}
+
+//CertStatus describes the expiry state of the enrollment certificate of a loaded identity
+type CertStatus struct {
+	ClientOrgID string
+	Identity    string
+	MSPID       string
+	NotAfter    time.Time
+	Remaining   time.Duration
+	//Threshold is the smallest configured threshold the remaining validity has crossed, zero if none was crossed
+	Threshold time.Duration
+	Expired   bool
+}
+
+//CertMonitorConfig defines the thresholds and handlers of the certificate expiry monitor
+type CertMonitorConfig struct {
+	//Interval between two checks. Defaults to one hour
+	Interval time.Duration
+	//Thresholds of remaining validity at which a warning is emitted. Defaults to 30 days, 7 days and 1 day
+	Thresholds []time.Duration
+	//Reenroll enables re-enrollment through the CA of configured identities. Identities registered from PEM are not re-enrolled
+	Reenroll bool
+	//ReenrollThreshold is the remaining validity below which an identity is re-enrolled. Defaults to the smallest threshold
+	ReenrollThreshold time.Duration
+	//OnWarning is called once per identity certificate for each crossed threshold. The library does not log: without it,
+	//the crossed thresholds are only reported in the statuses returned by Check
+	OnWarning func(status CertStatus)
+	//OnError is called when the certificate of an identity cannot be inspected. Without it, the error is ignored
+	OnError func(clientOrgID string, identity string, err error)
+	//OnMetric is called with the status of every inspected certificate on each check
+	OnMetric func(status CertStatus)
+	//OnReenroll is called after each re-enrollment attempt with its outcome
+	OnReenroll func(status CertStatus, err error)
+}
+
+//CertMonitor periodically inspects the x509 certificates of all identities loaded for the client orgs
+type CertMonitor struct {
+	cfgOptions ConfigOptions
+	cfg        CertMonitorConfig
+	mutex      sync.Mutex
+	warned     map[string]time.Duration
+	stop       chan struct{}
+	done       chan struct{}
+	startOnce  sync.Once
+	stopOnce   sync.Once
+}
+
+//NewCertMonitor returns a certificate expiry monitor for the identities of the config options
+func NewCertMonitor(cfgOptions ConfigOptions, cfg CertMonitorConfig) *CertMonitor {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultCertMonitorInterval
+	}
+	if len(cfg.Thresholds) == 0 {
+		cfg.Thresholds = defaultCertExpiryThresholds
+	}
+	thresholds := make([]time.Duration, len(cfg.Thresholds))
+	copy(thresholds, cfg.Thresholds)
+	sort.Slice(thresholds, func(i, j int) bool { return thresholds[i] < thresholds[j] })
+	cfg.Thresholds = thresholds
+	if cfg.ReenrollThreshold <= 0 {
+		cfg.ReenrollThreshold = cfg.Thresholds[0]
+	}
+	if cfg.OnWarning == nil {
+		cfg.OnWarning = func(CertStatus) {}
+	}
+	if cfg.OnError == nil {
+		cfg.OnError = func(string, string, error) {}
+	}
+	m := new(CertMonitor)
+	m.cfgOptions = cfgOptions
+	m.cfg = cfg
+	m.warned = make(map[string]time.Duration)
+	m.stop = make(chan struct{})
+	m.done = make(chan struct{})
+	return m
+}
+
+//Start runs the checks in the background until Stop is called
+func (m *CertMonitor) Start() {
+	m.startOnce.Do(func() {
+		go m.run()
+	})
+}
+
+//Stop stops the background checks and waits for a running check to complete
+func (m *CertMonitor) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.stop)
+	})
+	m.startOnce.Do(func() {
+		close(m.done)
+	})
+	<-m.done
+}
+
+func (m *CertMonitor) run() {
+	defer close(m.done)
+	ticker := time.NewTicker(m.cfg.Interval)
+	defer ticker.Stop()
+	m.Check()
+	for {
+		select {
+		case <-ticker.C:
+			m.Check()
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+//Check inspects the certificates once, emits warnings and metrics, re-enrolls if enabled and returns the statuses
+func (m *CertMonitor) Check() []CertStatus {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	var statuses []CertStatus
+	for _, clientOrgID := range m.cfgOptions.GetClientOrgs() {
+		for name, identity := range m.cfgOptions.GetLoadedIdentities(clientOrgID) {
+			status, err := m.inspect(clientOrgID, name, identity)
+			if err != nil {
+				m.cfg.OnError(clientOrgID, name, err)
+				continue
+			}
+			if status.Remaining <= m.cfg.ReenrollThreshold && m.cfg.Reenroll && m.cfgOptions.IsReenrollable(clientOrgID, name) {
+				renewed, err := m.cfgOptions.ReenrollIdentity(clientOrgID, name)
+				if m.cfg.OnReenroll != nil {
+					m.cfg.OnReenroll(status, err)
+				}
+				if err == nil {
+					if renewedStatus, err := m.inspect(clientOrgID, name, renewed); err == nil {
+						status = renewedStatus
+					}
+				}
+			}
+			m.report(status)
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
+func (m *CertMonitor) inspect(clientOrgID string, name string, identity mspapi.SigningIdentity) (CertStatus, error) {
+	var status CertStatus
+	cert, err := parseEnrollmentCertificate(identity)
+	if err != nil {
+		return status, err
+	}
+	status.ClientOrgID = clientOrgID
+	status.Identity = name
+	status.MSPID = identity.Identifier().MSPID
+	status.NotAfter = cert.NotAfter
+	status.Remaining = time.Until(cert.NotAfter)
+	status.Expired = status.Remaining <= 0
+	for _, threshold := range m.cfg.Thresholds {
+		if status.Remaining <= threshold {
+			status.Threshold = threshold
+			break
+		}
+	}
+	return status, nil
+}
+
+func (m *CertMonitor) report(status CertStatus) {
+	if m.cfg.OnMetric != nil {
+		m.cfg.OnMetric(status)
+	}
+	if status.Threshold == 0 {
+		return
+	}
+	key := status.ClientOrgID + "_" + status.Identity + "_" + status.NotAfter.String()
+	if warned, found := m.warned[key]; found && warned <= status.Threshold {
+		return
+	}
+	m.warned[key] = status.Threshold
+	m.cfg.OnWarning(status)
+}
+
+func parseEnrollmentCertificate(identity mspapi.SigningIdentity) (*x509.Certificate, error) {
+	if identity == nil {
+		return nil, errors.New("identity is not set")
+	}
+	block, _ := pem.Decode(identity.EnrollmentCertificate())
+	if block == nil {
+		return nil, errors.New("enrollment certificate is not PEM encoded")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
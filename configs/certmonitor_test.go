@@ -0,0 +1,204 @@
+package configs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	mspapi "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
+)
+
+//testSigningIdentity is a signing identity of Org1MSP with an enrollment certificate
+type testSigningIdentity struct {
+	mspapi.SigningIdentity
+	cert []byte
+}
+
+func (id *testSigningIdentity) Identifier() *mspapi.IdentityIdentifier {
+	return &mspapi.IdentityIdentifier{MSPID: "Org1MSP"}
+}
+
+func (id *testSigningIdentity) EnrollmentCertificate() []byte {
+	return id.cert
+}
+
+//newTestSigningIdentity returns an identity whose self-signed certificate expires after the remaining validity
+func newTestSigningIdentity(t *testing.T, remaining time.Duration) *testSigningIdentity {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "user"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(remaining),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testSigningIdentity{cert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+//testConfigOptions loads the identities of a single client org, org1, and re-enrolls the reenrollable ones with the renewed identity
+type testConfigOptions struct {
+	ConfigOptions
+	identities   map[string]mspapi.SigningIdentity
+	reenrollable map[string]bool
+	renewed      mspapi.SigningIdentity
+	reenrolled   []string
+}
+
+func (copts *testConfigOptions) GetClientOrgs() []string {
+	return []string{"org1"}
+}
+
+func (copts *testConfigOptions) GetLoadedIdentities(clientOrgID string) map[string]mspapi.SigningIdentity {
+	return copts.identities
+}
+
+func (copts *testConfigOptions) IsReenrollable(clientOrgID string, name string) bool {
+	return copts.reenrollable[name]
+}
+
+func (copts *testConfigOptions) ReenrollIdentity(clientOrgID string, username string) (mspapi.SigningIdentity, error) {
+	copts.reenrolled = append(copts.reenrolled, username)
+	copts.identities[username] = copts.renewed
+	return copts.renewed, nil
+}
+
+func TestCertMonitorThresholds(t *testing.T) {
+	const day = 24 * time.Hour
+	tests := []struct {
+		name      string
+		remaining time.Duration
+		threshold time.Duration
+		expired   bool
+	}{
+		{name: "expired", remaining: -time.Hour, threshold: day, expired: true},
+		{name: "expires within a day", remaining: 12 * time.Hour, threshold: day},
+		{name: "expires within a week", remaining: 3 * day, threshold: 7 * day},
+		{name: "expires within a month", remaining: 20 * day, threshold: 30 * day},
+		{name: "no threshold crossed", remaining: 60 * day},
+	}
+	m := NewCertMonitor(nil, CertMonitorConfig{})
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			status, err := m.inspect("org1", "user", newTestSigningIdentity(t, test.remaining))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status.Threshold != test.threshold || status.Expired != test.expired {
+				t.Errorf("expected threshold %s and expired %t, got %s and %t", test.threshold, test.expired, status.Threshold, status.Expired)
+			}
+			if status.MSPID != "Org1MSP" || status.ClientOrgID != "org1" || status.Identity != "user" {
+				t.Errorf("unexpected status %+v", status)
+			}
+		})
+	}
+
+	if _, err := m.inspect("org1", "user", nil); err == nil {
+		t.Error("expected an error without identity")
+	}
+	if _, err := m.inspect("org1", "user", &testSigningIdentity{cert: []byte("not a certificate")}); err == nil {
+		t.Error("expected an error for a certificate that is not PEM encoded")
+	}
+}
+
+func TestCertMonitorWarnings(t *testing.T) {
+	var warnings []time.Duration
+	m := NewCertMonitor(nil, CertMonitorConfig{OnWarning: func(status CertStatus) {
+		warnings = append(warnings, status.Threshold)
+	}})
+	notAfter := time.Now().Add(72 * time.Hour)
+	renewed := notAfter.Add(90 * 24 * time.Hour)
+	for _, status := range []CertStatus{
+		{Identity: "user", NotAfter: notAfter, Threshold: 7 * 24 * time.Hour},
+		{Identity: "user", NotAfter: notAfter, Threshold: 7 * 24 * time.Hour},
+		{Identity: "user", NotAfter: notAfter, Threshold: 24 * time.Hour},
+		{Identity: "user", NotAfter: notAfter, Threshold: 7 * 24 * time.Hour},
+		{Identity: "user", NotAfter: renewed},
+		{Identity: "user", NotAfter: renewed, Threshold: 30 * 24 * time.Hour},
+	} {
+		m.report(status)
+	}
+	//Each certificate is warned once per crossed threshold
+	want := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, 30 * 24 * time.Hour}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("expected warnings %v, got %v", want, warnings)
+	}
+}
+
+func TestCertMonitorCheck(t *testing.T) {
+	const day = 24 * time.Hour
+	cfgOptions := &testConfigOptions{
+		identities: map[string]mspapi.SigningIdentity{
+			"user":   newTestSigningIdentity(t, 12*time.Hour),
+			"admin":  newTestSigningIdentity(t, 60*day),
+			"pem":    newTestSigningIdentity(t, 3*day),
+			"broken": &testSigningIdentity{cert: []byte("not a certificate")},
+		},
+		reenrollable: map[string]bool{"user": true, "admin": true},
+		renewed:      newTestSigningIdentity(t, 90*day),
+	}
+	var warned, failed, reenrolled []string
+	metrics := 0
+	m := NewCertMonitor(cfgOptions, CertMonitorConfig{
+		Reenroll:          true,
+		ReenrollThreshold: 5 * day,
+		OnWarning:         func(status CertStatus) { warned = append(warned, status.Identity) },
+		OnError:           func(clientOrgID string, identity string, err error) { failed = append(failed, identity) },
+		OnMetric:          func(status CertStatus) { metrics++ },
+		OnReenroll: func(status CertStatus, err error) {
+			if err != nil {
+				t.Errorf("unexpected re-enrollment error %v", err)
+			}
+			reenrolled = append(reenrolled, status.Identity)
+		},
+	})
+
+	thresholds := make(map[string]time.Duration)
+	for _, status := range m.Check() {
+		thresholds[status.Identity] = status.Threshold
+	}
+	//The user certificate is re-enrolled before its warning, the PEM identity is not reenrollable
+	if want := map[string]time.Duration{"user": 0, "admin": 0, "pem": 7 * day}; !reflect.DeepEqual(thresholds, want) {
+		t.Errorf("expected thresholds %v, got %v", want, thresholds)
+	}
+	if !reflect.DeepEqual(cfgOptions.reenrolled, []string{"user"}) || !reflect.DeepEqual(reenrolled, []string{"user"}) {
+		t.Errorf("expected user to be re-enrolled, got %v and %v", cfgOptions.reenrolled, reenrolled)
+	}
+	if !reflect.DeepEqual(warned, []string{"pem"}) {
+		t.Errorf("expected a warning for pem, got %v", warned)
+	}
+	if !reflect.DeepEqual(failed, []string{"broken"}) {
+		t.Errorf("expected an error for broken, got %v", failed)
+	}
+
+	m.Check()
+	if !reflect.DeepEqual(warned, []string{"pem"}) || len(cfgOptions.reenrolled) != 1 {
+		t.Errorf("expected no new warning nor re-enrollment on the second check, got warnings %v and re-enrollments %v", warned, cfgOptions.reenrolled)
+	}
+	if metrics != 6 {
+		t.Errorf("expected 6 metrics, got %d", metrics)
+	}
+}
+
+func TestCertMonitorStop(t *testing.T) {
+	//Stop returns whether or not the monitor was started
+	NewCertMonitor(&testConfigOptions{}, CertMonitorConfig{}).Stop()
+
+	m := NewCertMonitor(&testConfigOptions{}, CertMonitorConfig{Interval: time.Millisecond})
+	m.Start()
+	time.Sleep(5 * time.Millisecond)
+	m.Stop()
+	m.Stop()
+}
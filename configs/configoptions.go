@@ -1,6 +1,12 @@
 package configs
 
 import (
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/msp"
 	fabapi "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 	mspapi "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
@@ -10,33 +16,81 @@ import (
 )
 
 type configOptionService struct {
-	appCfgMap     map[string]*appConfig
+	mutex         sync.RWMutex
+	appCfgMap     map[string]*ClientOrgConfig
 	networkCfgMap map[string]*networkConfig
+	//source is kept to reload the app config
+	source      appConfigSource
+	reloadMutex sync.Mutex
+	//appConfigFile is the path of the fabricApp.json, empty if the app config was not loaded from a file
+	appConfigFile string
+	//parent is set on the views returned by Pin
+	parent  *configOptionService
+	watcher *configWatcher
 }
 
 //ConfigOptions struct defines the config properties of the app/chaincode and fabric network
 type ConfigOptions interface {
-	GetUserName(clientOrgID string) string
-	GetFabricSDK(clientOrgID string) *fabsdk.FabricSDK
-	GetClientOrgID(clientOrgID string) string
-	GetClientOrgMSPID(clientOrgID string) string
-	GetClientOrgPeers(clientOrgID string) []fabapi.Peer
-	GetClientOrgUser(clientOrgID string) mspapi.SigningIdentity
-	GetClientOrgAdminUser(clientOrgID string) mspapi.SigningIdentity
-	GetOrgsID(clientOrgID string) []string
-	GetOrgsMSPByOrgID(clientOrgID string) map[string]string
-	GetOrgsIDByPeers(clientOrgID string) map[string]string
+	GetUserName(clientOrgID string) (string, error)
+	GetFabricSDK(clientOrgID string) (*fabsdk.FabricSDK, error)
+	GetClientOrgID(clientOrgID string) (string, error)
+	GetClientOrgMSPID(clientOrgID string) (string, error)
+	GetClientOrgPeers(clientOrgID string) ([]fabapi.Peer, error)
+	GetClientOrgUser(clientOrgID string) (mspapi.SigningIdentity, error)
+	GetClientOrgAdminUser(clientOrgID string) (mspapi.SigningIdentity, error)
+	GetClientOrgAdminUsers(clientOrgID string) ([]mspapi.SigningIdentity, error)
+	GetOrgAdminNames(clientOrgID string, orgID string) ([]string, error)
+	GetOrgsID(clientOrgID string) ([]string, error)
+	GetOrgsMSPByOrgID(clientOrgID string) (map[string]string, error)
+	GetOrgsIDByPeers(clientOrgID string) (map[string]string, error)
 	GetClientOrgs() []string
-	GetAllPeersByOrg(clientOrgID string) map[string][]fabapi.Peer
+	GetAllPeersByOrg(clientOrgID string) (map[string][]fabapi.Peer, error)
+	RegisterIdentity(clientOrgID string, name string, certPEM []byte, keyPEM []byte) (mspapi.SigningIdentity, error)
+	GetIdentity(clientOrgID string, name string) (mspapi.SigningIdentity, bool)
+	GetLoadedIdentities(clientOrgID string) map[string]mspapi.SigningIdentity
+	ReenrollIdentity(clientOrgID string, username string) (mspapi.SigningIdentity, error)
+	IsReenrollable(clientOrgID string, name string) bool
+	Pin(clientOrgID string) (ConfigOptions, func(), error)
+	IsCurrent(clientOrgID string) bool
+	Reload() error
+	Watch(onReload func(err error)) error
+	Close()
 }
 
-//NewConfigOptions initializes the ConfigOptions struct
+//NewConfigOptions initializes the ConfigOptions struct from the fabricApp.json in the config path
 func NewConfigOptions(configPath string) (ConfigOptions, error) {
+	return newConfigOptions(appConfigFromPath(configPath), filepath.Join(configPath, appConfigFile))
+}
+
+//NewConfigOptionsFromReader initializes the ConfigOptions struct from an app config of the config type (json, yaml...) read from r
+func NewConfigOptionsFromReader(r io.Reader, configType string) (ConfigOptions, error) {
+	//The app config is buffered so that it can be reloaded
+	raw, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Errorf("Initialization of App Config failed with the error: %s", err.Error())
+	}
+	return NewConfigOptionsFromBytes(raw, configType)
+}
+
+//NewConfigOptionsFromBytes initializes the ConfigOptions struct from the raw app config of the config type (json, yaml...)
+func NewConfigOptionsFromBytes(raw []byte, configType string) (ConfigOptions, error) {
+	return newConfigOptions(appConfigFromBytes(raw, configType), "")
+}
+
+//NewConfigOptionsFromAppConfig initializes the ConfigOptions struct from the app config
+func NewConfigOptionsFromAppConfig(appCfg AppConfig) (ConfigOptions, error) {
+	return newConfigOptions(appConfigFromMap(appCfg.toMap()), "")
+}
+
+func newConfigOptions(source appConfigSource, appConfigFile string) (ConfigOptions, error) {
 	cfgOptions := new(configOptionService)
+	cfgOptions.source = source
+	cfgOptions.appConfigFile = appConfigFile
 	//Init App config
-	err := cfgOptions.initAppCfg(configPath)
+	err := cfgOptions.initAppCfg(source)
 	if err != nil {
-		return nil, errors.Errorf("Initialization of App Config failed with the error: %s", err.Error())
+		//Wrapped with a message so that errors.Cause returns the *ValidationError
+		return nil, errors.WithMessage(err, "Initialization of App Config failed with the error")
 	}
 	//Init organisations
 	//Init peers
@@ -49,8 +103,37 @@ func NewConfigOptions(configPath string) (ConfigOptions, error) {
 	return cfgOptions, nil
 }
 
-func (copts *configOptionService) GetUserName(clientOrgID string) string {
-	return copts.appCfgMap[clientOrgID].getUser()
+func (copts *configOptionService) networkCfg(clientOrgID string) *networkConfig {
+	copts.mutex.RLock()
+	defer copts.mutex.RUnlock()
+	return copts.networkCfgMap[clientOrgID]
+}
+
+//orgConfig returns the network config of the client org, or an error if the client org is not configured
+func (copts *configOptionService) orgConfig(clientOrgID string) (*networkConfig, error) {
+	netCfg, found := copts.lookupNetworkCfg(clientOrgID)
+	if !found {
+		return nil, errors.Errorf("Client org %s is not configured", clientOrgID)
+	}
+	return netCfg, nil
+}
+
+func (copts *configOptionService) lookupNetworkCfg(clientOrgID string) (*networkConfig, bool) {
+	copts.mutex.RLock()
+	defer copts.mutex.RUnlock()
+	netCfg, found := copts.networkCfgMap[clientOrgID]
+	return netCfg, found
+}
+
+//GetUserName returns the user of the client org, or an error if the client org is not configured
+func (copts *configOptionService) GetUserName(clientOrgID string) (string, error) {
+	copts.mutex.RLock()
+	defer copts.mutex.RUnlock()
+	appCfg, found := copts.appCfgMap[clientOrgID]
+	if !found {
+		return "", errors.Errorf("Client org %s is not configured", clientOrgID)
+	}
+	return appCfg.User, nil
 }
 
 /* func (copts *configOptionService) GetPolicy() (*common.SignaturePolicyEnvelope, error) {
@@ -60,43 +143,97 @@ func (copts *configOptionService) GetUserName(clientOrgID string) string {
 	return cauthdsl.AcceptAllPolicy, nil
 } */
 
-func (copts *configOptionService) GetFabricSDK(clientOrgID string) *fabsdk.FabricSDK {
-	return copts.networkCfgMap[clientOrgID].sdk
+func (copts *configOptionService) GetFabricSDK(clientOrgID string) (*fabsdk.FabricSDK, error) {
+	netCfg, err := copts.orgConfig(clientOrgID)
+	if err != nil {
+		return nil, err
+	}
+	return netCfg.sdk, nil
 }
 
-func (copts *configOptionService) GetClientOrgID(clientOrgID string) string {
-	return copts.networkCfgMap[clientOrgID].clientOrgID
+func (copts *configOptionService) GetClientOrgID(clientOrgID string) (string, error) {
+	netCfg, err := copts.orgConfig(clientOrgID)
+	if err != nil {
+		return "", err
+	}
+	return netCfg.clientOrgID, nil
 }
 
-func (copts *configOptionService) GetClientOrgMSPID(clientOrgID string) string {
-	return copts.networkCfgMap[clientOrgID].clientOrgMSPID
+func (copts *configOptionService) GetClientOrgMSPID(clientOrgID string) (string, error) {
+	netCfg, err := copts.orgConfig(clientOrgID)
+	if err != nil {
+		return "", err
+	}
+	return netCfg.clientOrgMSPID, nil
 }
 
-func (copts *configOptionService) GetClientOrgPeers(clientOrgID string) []fabapi.Peer {
-	return copts.networkCfgMap[clientOrgID].clientOrgPeers
+func (copts *configOptionService) GetClientOrgPeers(clientOrgID string) ([]fabapi.Peer, error) {
+	netCfg, err := copts.orgConfig(clientOrgID)
+	if err != nil {
+		return nil, err
+	}
+	return netCfg.clientOrgPeers, nil
 }
 
-func (copts *configOptionService) GetClientOrgUser(clientOrgID string) mspapi.SigningIdentity {
-	return copts.networkCfgMap[clientOrgID].clientOrgUser
+func (copts *configOptionService) GetClientOrgUser(clientOrgID string) (mspapi.SigningIdentity, error) {
+	netCfg, err := copts.orgConfig(clientOrgID)
+	if err != nil {
+		return nil, err
+	}
+	return netCfg.user(), nil
+}
+
+func (copts *configOptionService) GetClientOrgAdminUser(clientOrgID string) (mspapi.SigningIdentity, error) {
+	netCfg, err := copts.orgConfig(clientOrgID)
+	if err != nil {
+		return nil, err
+	}
+	return netCfg.adminUser(), nil
+}
+
+func (copts *configOptionService) GetClientOrgAdminUsers(clientOrgID string) ([]mspapi.SigningIdentity, error) {
+	netCfg, err := copts.orgConfig(clientOrgID)
+	if err != nil {
+		return nil, err
+	}
+	return netCfg.admins(), nil
 }
 
-func (copts *configOptionService) GetClientOrgAdminUser(clientOrgID string) mspapi.SigningIdentity {
-	return copts.networkCfgMap[clientOrgID].clientOrgAdminUser
+func (copts *configOptionService) GetOrgAdminNames(clientOrgID string, orgID string) ([]string, error) {
+	netCfg, err := copts.orgConfig(clientOrgID)
+	if err != nil {
+		return nil, err
+	}
+	return netCfg.orgAdmins(orgID), nil
 }
 
-func (copts *configOptionService) GetOrgsID(clientOrgID string) []string {
-	return copts.networkCfgMap[clientOrgID].orgsID
+func (copts *configOptionService) GetOrgsID(clientOrgID string) ([]string, error) {
+	netCfg, err := copts.orgConfig(clientOrgID)
+	if err != nil {
+		return nil, err
+	}
+	return netCfg.orgsID, nil
 }
 
-func (copts *configOptionService) GetOrgsMSPByOrgID(clientOrgID string) map[string]string {
-	return copts.networkCfgMap[clientOrgID].orgsMSPByOrgID
+func (copts *configOptionService) GetOrgsMSPByOrgID(clientOrgID string) (map[string]string, error) {
+	netCfg, err := copts.orgConfig(clientOrgID)
+	if err != nil {
+		return nil, err
+	}
+	return netCfg.orgsMSPByOrgID, nil
 }
 
-func (copts *configOptionService) GetOrgsIDByPeers(clientOrgID string) map[string]string {
-	return copts.networkCfgMap[clientOrgID].orgsIDByPeers
+func (copts *configOptionService) GetOrgsIDByPeers(clientOrgID string) (map[string]string, error) {
+	netCfg, err := copts.orgConfig(clientOrgID)
+	if err != nil {
+		return nil, err
+	}
+	return netCfg.orgsIDByPeers, nil
 }
 
 func (copts *configOptionService) GetClientOrgs() []string {
+	copts.mutex.RLock()
+	defer copts.mutex.RUnlock()
 	var orgs []string
 	for org := range copts.appCfgMap {
 		orgs = append(orgs, org)
@@ -104,16 +241,93 @@ func (copts *configOptionService) GetClientOrgs() []string {
 	return orgs
 }
 
-func (copts *configOptionService) GetAllPeersByOrg(clientOrgID string) map[string][]fabapi.Peer {
-	return copts.networkCfgMap[clientOrgID].peersByOrg
+func (copts *configOptionService) GetAllPeersByOrg(clientOrgID string) (map[string][]fabapi.Peer, error) {
+	netCfg, err := copts.orgConfig(clientOrgID)
+	if err != nil {
+		return nil, err
+	}
+	return netCfg.peersByOrg, nil
+}
+
+//RegisterIdentity creates a signing identity of the client org from PEM certificate and key bytes and registers it under the name
+func (copts *configOptionService) RegisterIdentity(clientOrgID string, name string, certPEM []byte, keyPEM []byte) (mspapi.SigningIdentity, error) {
+	netCfg, err := copts.orgConfig(clientOrgID)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := NewSigningIdentityFromPEM(netCfg.sdk, netCfg.clientOrgID, certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	netCfg.identities.register(name, identity)
+	return identity, nil
+}
+
+//GetIdentity returns the signing identity registered under the name for the client org
+func (copts *configOptionService) GetIdentity(clientOrgID string, name string) (mspapi.SigningIdentity, bool) {
+	netCfg, found := copts.lookupNetworkCfg(clientOrgID)
+	if !found {
+		return nil, false
+	}
+	return netCfg.identities.get(name)
+}
+
+//GetLoadedIdentities returns every identity loaded for the client org, configured and registered, keyed by name
+func (copts *configOptionService) GetLoadedIdentities(clientOrgID string) map[string]mspapi.SigningIdentity {
+	netCfg, found := copts.lookupNetworkCfg(clientOrgID)
+	if !found {
+		return nil
+	}
+	identities := netCfg.identities.all()
+	for name, identity := range netCfg.loadedIdentities() {
+		identities[name] = identity
+	}
+	return identities
 }
 
-func (copts *configOptionService) initAppCfg(appConfigPath string) error {
-	appConfigMap, err := initAppConfig(appConfigPath)
+//ReenrollIdentity re-enrolls the configured user or admin identity with the client org CA and swaps in the renewed identity
+func (copts *configOptionService) ReenrollIdentity(clientOrgID string, username string) (mspapi.SigningIdentity, error) {
+	netCfg, err := copts.orgConfig(clientOrgID)
+	if err != nil {
+		return nil, err
+	}
+	if !copts.IsReenrollable(clientOrgID, username) {
+		return nil, errors.Errorf("identity %s of client org %s was not loaded from the CA and cannot be re-enrolled", username, clientOrgID)
+	}
+	mspClient, err := msp.New(netCfg.sdk.Context(), msp.WithOrg(netCfg.clientOrgID))
+	if err != nil {
+		return nil, errors.Errorf("error creating MSP client: %s", err)
+	}
+	if err := mspClient.Reenroll(username); err != nil {
+		return nil, errors.Errorf("Reenroll for %s returned error: %v", username, err)
+	}
+	identity, err := mspClient.GetSigningIdentity(username)
+	if err != nil {
+		return nil, errors.Errorf("GetSigningIdentity for %s returned error: %v", username, err)
+	}
+	netCfg.swapIdentity(username, identity)
+	return identity, nil
+}
+
+//IsReenrollable reports whether the identity was loaded from the CA and can be re-enrolled, unlike the identities registered from PEM
+func (copts *configOptionService) IsReenrollable(clientOrgID string, name string) bool {
+	netCfg, found := copts.lookupNetworkCfg(clientOrgID)
+	if !found {
+		return false
+	}
+	_, found = netCfg.loadedIdentities()[name]
+	return found
+}
+
+func (copts *configOptionService) initAppCfg(source appConfigSource) error {
+	appCfg, err := initAppConfig(source, DefaultEnvPrefix)
 	if err != nil {
 		return err
 	}
-	copts.appCfgMap = appConfigMap
+	copts.appCfgMap = make(map[string]*ClientOrgConfig)
+	for i := range appCfg.ClientOrgs {
+		copts.appCfgMap[appCfg.ClientOrgs[i].Name] = &appCfg.ClientOrgs[i]
+	}
 	return nil
 }
 
@@ -123,8 +337,12 @@ func (copts *configOptionService) initNetworkCfg() error {
 	}
 	copts.networkCfgMap = make(map[string]*networkConfig)
 	for orgid, appCfg := range copts.appCfgMap {
-		networkConfig, err := initNetworkConfig(appCfg.getNetworkConfigPath(), appCfg.getUser())
+		networkConfig, err := newOrgNetworkConfig(appCfg)
 		if err != nil {
+			//Close the SDKs already created so that a failed initialization does not leak them
+			for _, netCfg := range copts.networkCfgMap {
+				netCfg.retire()
+			}
 			return err
 		}
 		copts.networkCfgMap[orgid] = networkConfig
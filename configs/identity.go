@@ -0,0 +1,82 @@
+package configs
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"sync"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/msp"
+	mspapi "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"github.com/pkg/errors"
+)
+
+//identityRegistry holds the signing identities registered for a client org at runtime
+type identityRegistry struct {
+	mutex      sync.RWMutex
+	identities map[string]mspapi.SigningIdentity
+}
+
+func newIdentityRegistry() *identityRegistry {
+	return &identityRegistry{identities: make(map[string]mspapi.SigningIdentity)}
+}
+
+func (r *identityRegistry) register(name string, identity mspapi.SigningIdentity) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.identities[name] = identity
+}
+
+func (r *identityRegistry) get(name string) (mspapi.SigningIdentity, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	identity, found := r.identities[name]
+	return identity, found
+}
+
+func (r *identityRegistry) all() map[string]mspapi.SigningIdentity {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	identities := make(map[string]mspapi.SigningIdentity, len(r.identities))
+	for name, identity := range r.identities {
+		identities[name] = identity
+	}
+	return identities
+}
+
+//NewSigningIdentityFromPEM creates a signing identity of the org from a PEM encoded certificate and private key.
+//The private key is imported into the SDK crypto suite as an ephemeral key and is never written to the filesystem.
+func NewSigningIdentityFromPEM(sdk *fabsdk.FabricSDK, orgID string, certPEM []byte, keyPEM []byte) (mspapi.SigningIdentity, error) {
+	if sdk == nil {
+		return nil, errors.New("Fabric SDK is not set")
+	}
+	certBlock, _ := pem.Decode(certPEM)
+	if certBlock == nil {
+		return nil, errors.New("certificate is not PEM encoded")
+	}
+	if _, err := x509.ParseCertificate(certBlock.Bytes); err != nil {
+		return nil, errors.Errorf("failed to parse certificate. Error: %s", err.Error())
+	}
+	keyBlock, _ := pem.Decode(keyPEM)
+	if keyBlock == nil {
+		return nil, errors.New("private key is not PEM encoded")
+	}
+	ctx, err := sdk.Context()()
+	if err != nil {
+		return nil, errors.Errorf("failed to retrieve SDK context. Error: %s", err.Error())
+	}
+	privateKey, err := ctx.CryptoSuite().KeyImport(keyBlock.Bytes, cryptosuite.GetECDSAPrivateKeyImportOpts(true))
+	if err != nil {
+		return nil, errors.Errorf("failed to import private key. Error: %s", err.Error())
+	}
+	mspClient, err := msp.New(sdk.Context(), msp.WithOrg(orgID))
+	if err != nil {
+		return nil, errors.Errorf("error creating MSP client: %s", err)
+	}
+	identity, err := mspClient.CreateSigningIdentity(mspapi.WithCert(certPEM), mspapi.WithPrivateKey(privateKey))
+	if err != nil {
+		return nil, errors.Errorf("CreateSigningIdentity for org %s returned error: %v", orgID, err)
+	}
+	return identity, nil
+}
@@ -1,15 +1,17 @@
 package configs
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/msp"
 	fabapi "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 	mspapi "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
-	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
 )
 
@@ -22,17 +24,28 @@ type networkConfig struct {
 	clientOrgID        string
 	clientOrgMSPID     string
 	clientOrgPeers     []fabapi.Peer
+	identityMutex      sync.RWMutex
+	clientOrgUserName  string
 	clientOrgUser      mspapi.SigningIdentity
 	clientOrgAdminUser mspapi.SigningIdentity
+	clientOrgAdmins    []mspapi.SigningIdentity
+	adminsByOrg        map[string][]string
+	identities         *identityRegistry
 	orgsID             []string
 	orgsMSPByOrgID     map[string]string
 	orgsIDByPeers      map[string]string
 	peersByOrg         map[string][]fabapi.Peer
+
+	//orgCfg and profileDigest are the app config and connection profile digest the network config was built from
+	orgCfg        ClientOrgConfig
+	profileDigest [sha256.Size]byte
+	refMutex      sync.Mutex
+	refs          int
+	retired       bool
 }
 
-func getNetworkConfig(networkConfigPath string) (*networkConfig, error) {
+func getNetworkConfig(networkCfgProvider core.ConfigProvider) (*networkConfig, error) {
 	var netCfg = new(networkConfig)
-	networkCfgProvider := config.FromFile(networkConfigPath)
 	sdk, err := fabsdk.New(networkCfgProvider)
 	if err != nil {
 		return nil, err
@@ -40,6 +53,7 @@ func getNetworkConfig(networkConfigPath string) (*networkConfig, error) {
 	clientProvider := sdk.Context()
 	ctx, err1 := clientProvider()
 	if err1 != nil {
+		sdk.Close()
 		return nil, err1
 	}
 	endpointCfg := ctx.EndpointConfig()
@@ -47,26 +61,38 @@ func getNetworkConfig(networkConfigPath string) (*networkConfig, error) {
 	netCfg.sdk = sdk
 	netCfg.endpointCfg = endpointCfg
 	netCfg.identityCfg = identityCfg
-	return nil, nil
+	netCfg.identities = newIdentityRegistry()
+	return netCfg, nil
 }
 
-func initNetworkConfig(networkConfigPath string, username string) (*networkConfig, error) {
-	netCfg, err := getNetworkConfig(networkConfigPath)
+func initNetworkConfig(networkCfgProvider core.ConfigProvider, username string, adminsByOrg map[string][]string) (_ *networkConfig, err error) {
+	netCfg, err := getNetworkConfig(networkCfgProvider)
 	if err != nil {
 		return nil, errors.Errorf("Network config initialization failed with error: %s", err.Error())
 	}
+	//The SDK is closed if the initialization fails, the network config being discarded. Reloads would leak one on each failed attempt otherwise.
+	sdk := netCfg.sdk
+	defer func() {
+		if err != nil {
+			sdk.Close()
+		}
+	}()
 	netCfg.initClientOrg()
+	netCfg.adminsByOrg = adminsByOrg
 	if err := netCfg.initClientOrgMSPID(); err != nil {
 		return nil, errors.Errorf("Network config initialization failed with error: %s", err.Error())
 	}
 	if err := netCfg.initClientOrgPeers(); err != nil {
 		return nil, errors.Errorf("Network config initialization failed with error: %s", err.Error())
 	}
-	if err := netCfg.initClientOrgUser(username); err != nil {
-		return nil, errors.Errorf("Network config initialization failed with error: %s", err.Error())
-	}
-	if err := netCfg.initClientOrgAdminUser(); err != nil {
-		return nil, errors.Errorf("Network config initialization failed with error: %s", err.Error())
+	//A client org without user has no keystore to load identities from, its transactions being signed by external signers
+	if username != "" {
+		if err := netCfg.initClientOrgUser(username); err != nil {
+			return nil, errors.Errorf("Network config initialization failed with error: %s", err.Error())
+		}
+		if err := netCfg.initClientOrgAdminUser(); err != nil {
+			return nil, errors.Errorf("Network config initialization failed with error: %s", err.Error())
+		}
 	}
 	if err := netCfg.initOrgs(); err != nil {
 		return nil, errors.Errorf("Network config initialization failed with error: %s", err.Error())
@@ -124,24 +150,95 @@ func (netCfg *networkConfig) initClientOrgUser(username string) error {
 	if err != nil {
 		return errors.Errorf("GetSigningIdentity for %s returned error: %v", username, err)
 	}
+	netCfg.clientOrgUserName = username
 	netCfg.clientOrgUser = user
 	return nil
 }
 
 func (netCfg *networkConfig) initClientOrgAdminUser() error {
-	username := adminUser
 	mspClient, err := msp.New(netCfg.sdk.Context(), msp.WithOrg(netCfg.clientOrgID))
 	if err != nil {
 		return errors.Errorf("error creating MSP client: %s", err)
 	}
-	admin, err := mspClient.GetSigningIdentity(username)
-	if err != nil {
-		return errors.Errorf("GetSigningIdentity for %s returned error: %v", username, err)
+	for _, username := range netCfg.orgAdmins(netCfg.clientOrgID) {
+		admin, err := mspClient.GetSigningIdentity(username)
+		if err != nil {
+			return errors.Errorf("GetSigningIdentity for %s returned error: %v", username, err)
+		}
+		netCfg.clientOrgAdmins = append(netCfg.clientOrgAdmins, admin)
 	}
-	netCfg.clientOrgAdminUser = admin
+	netCfg.clientOrgAdminUser = netCfg.clientOrgAdmins[0]
 	return nil
 }
 
+func (netCfg *networkConfig) user() mspapi.SigningIdentity {
+	netCfg.identityMutex.RLock()
+	defer netCfg.identityMutex.RUnlock()
+	return netCfg.clientOrgUser
+}
+
+func (netCfg *networkConfig) adminUser() mspapi.SigningIdentity {
+	netCfg.identityMutex.RLock()
+	defer netCfg.identityMutex.RUnlock()
+	return netCfg.clientOrgAdminUser
+}
+
+func (netCfg *networkConfig) admins() []mspapi.SigningIdentity {
+	netCfg.identityMutex.RLock()
+	defer netCfg.identityMutex.RUnlock()
+	admins := make([]mspapi.SigningIdentity, len(netCfg.clientOrgAdmins))
+	copy(admins, netCfg.clientOrgAdmins)
+	return admins
+}
+
+//loadedIdentities returns the configured user and admin identities of the client org keyed by username
+func (netCfg *networkConfig) loadedIdentities() map[string]mspapi.SigningIdentity {
+	netCfg.identityMutex.RLock()
+	defer netCfg.identityMutex.RUnlock()
+	identities := make(map[string]mspapi.SigningIdentity)
+	if netCfg.clientOrgUser == nil {
+		return identities
+	}
+	identities[netCfg.clientOrgUserName] = netCfg.clientOrgUser
+	for i, name := range netCfg.orgAdmins(netCfg.clientOrgID) {
+		identities[name] = netCfg.clientOrgAdmins[i]
+	}
+	return identities
+}
+
+//swapIdentity replaces the configured user or admin identity loaded under the username
+func (netCfg *networkConfig) swapIdentity(username string, identity mspapi.SigningIdentity) bool {
+	netCfg.identityMutex.Lock()
+	defer netCfg.identityMutex.Unlock()
+	swapped := false
+	if netCfg.clientOrgUser == nil {
+		return swapped
+	}
+	if username == netCfg.clientOrgUserName {
+		netCfg.clientOrgUser = identity
+		swapped = true
+	}
+	for i, name := range netCfg.orgAdmins(netCfg.clientOrgID) {
+		if name == username {
+			netCfg.clientOrgAdmins[i] = identity
+			swapped = true
+		}
+	}
+	if len(netCfg.clientOrgAdmins) > 0 {
+		netCfg.clientOrgAdminUser = netCfg.clientOrgAdmins[0]
+	}
+	return swapped
+}
+
+//orgAdmins returns the admin usernames configured for the org, falling back to the default admin user
+func (netCfg *networkConfig) orgAdmins(orgID string) []string {
+	names := netCfg.adminsByOrg[strings.ToLower(orgID)]
+	if len(names) == 0 {
+		return []string{adminUser}
+	}
+	return names
+}
+
 func (netCfg *networkConfig) initOrgs() error {
 	orgCfgMap := netCfg.endpointCfg.NetworkConfig().Organizations
 	if netCfg.orgsMSPByOrgID == nil {
@@ -201,3 +298,33 @@ func (netCfg *networkConfig) initParticipatingOrgPeers() error {
 	netCfg.peersByOrg = peersByOrg
 	return nil
 }
+
+//acquire marks the network config as in use so that its SDK is not closed when it is retired
+func (netCfg *networkConfig) acquire() {
+	netCfg.refMutex.Lock()
+	defer netCfg.refMutex.Unlock()
+	netCfg.refs++
+}
+
+//release marks the network config as no longer in use by an acquirer and closes its SDK if it was retired meanwhile
+func (netCfg *networkConfig) release() {
+	netCfg.refMutex.Lock()
+	defer netCfg.refMutex.Unlock()
+	netCfg.refs--
+	if netCfg.refs == 0 && netCfg.retired {
+		netCfg.sdk.Close()
+	}
+}
+
+//retire closes the SDK once the network config is no longer in use
+func (netCfg *networkConfig) retire() {
+	netCfg.refMutex.Lock()
+	defer netCfg.refMutex.Unlock()
+	if netCfg.retired {
+		return
+	}
+	netCfg.retired = true
+	if netCfg.refs == 0 {
+		netCfg.sdk.Close()
+	}
+}
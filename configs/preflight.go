@@ -0,0 +1,390 @@
+package configs
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+//Severity of a preflight finding
+type Severity string
+
+//List of preflight finding severities
+const (
+	SeverityError   Severity = "ERROR"
+	SeverityWarning Severity = "WARNING"
+)
+
+//Finding is a single problem found by the preflight validation
+type Finding struct {
+	Severity Severity `json:"severity"`
+	//ClientOrg is the client org whose config or connection profile the finding relates to, empty for the app config
+	ClientOrg string `json:"clientOrg,omitempty"`
+	//Field is the path of the offending key, e.g. peers.peer0.org1.example.com.tlscacerts.path
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (f Finding) String() string {
+	prefix := string(f.Severity)
+	if f.ClientOrg != "" {
+		prefix += " [" + f.ClientOrg + "]"
+	}
+	return prefix + " " + f.Field + ": " + f.Message
+}
+
+//Report is the structured result of the preflight validation
+type Report struct {
+	Findings []Finding `json:"findings"`
+}
+
+//OK reports whether the validation found no error. Warnings do not fail the validation.
+func (r *Report) OK() bool {
+	return len(r.Errors()) == 0
+}
+
+//Errors returns the findings of error severity
+func (r *Report) Errors() []Finding {
+	var errs []Finding
+	for _, f := range r.Findings {
+		if f.Severity == SeverityError {
+			errs = append(errs, f)
+		}
+	}
+	return errs
+}
+
+func (r *Report) String() string {
+	if len(r.Findings) == 0 {
+		return "No problem found"
+	}
+	lines := make([]string, len(r.Findings))
+	for i, f := range r.Findings {
+		lines[i] = f.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (r *Report) add(severity Severity, clientOrg string, field string, format string, args ...interface{}) {
+	r.Findings = append(r.Findings, Finding{Severity: severity, ClientOrg: clientOrg, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+//Validate runs the preflight validation of the fabricApp.json in the config path and of every connection profile it references.
+//It checks that every peer, orderer and CA referenced by the organizations and channels is defined, that TLS CA certs and
+//crypto paths exist and parse as certificates, and that the user and admin identities resolve for each org.
+//Nothing is sent to the network.
+func Validate(configPath string) *Report {
+	return validate(appConfigFromPath(configPath))
+}
+
+//ValidateAppConfigPreflight runs the preflight validation of the app config and of every connection profile it references
+func ValidateAppConfigPreflight(appCfg AppConfig) *Report {
+	return validate(appConfigFromMap(appCfg.toMap()))
+}
+
+func validate(source appConfigSource) *Report {
+	report := new(Report)
+	appCfg, err := initAppConfig(source, DefaultEnvPrefix)
+	if err != nil {
+		if problems, ok := err.(*ValidationError); ok {
+			for _, p := range problems.Problems {
+				report.add(SeverityError, "", p.Field, p.Message)
+			}
+		} else {
+			report.add(SeverityError, "", appConfigFile, "failed to load app config: %s", err.Error())
+		}
+		return report
+	}
+	for i := range appCfg.ClientOrgs {
+		validateConnectionProfile(report, &appCfg.ClientOrgs[i])
+	}
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		if report.Findings[i].ClientOrg != report.Findings[j].ClientOrg {
+			return report.Findings[i].ClientOrg < report.Findings[j].ClientOrg
+		}
+		return report.Findings[i].Field < report.Findings[j].Field
+	})
+	return report
+}
+
+//connectionProfile holds the sections of a connection profile checked by the preflight validation
+type connectionProfile struct {
+	clientOrg string
+	report    *Report
+	client    map[string]interface{}
+	channels  map[string]interface{}
+	orgs      map[string]interface{}
+	peers     map[string]interface{}
+	orderers  map[string]interface{}
+	cas       map[string]interface{}
+}
+
+func validateConnectionProfile(report *Report, orgCfg *ClientOrgConfig) {
+	v := viper.New()
+	var err error
+	if len(orgCfg.NetworkConfig) > 0 {
+		cfgType := orgCfg.NetworkConfigType
+		if cfgType == "" {
+			cfgType = defaultNetworkConfigType
+		}
+		v.SetConfigType(cfgType)
+		err = v.ReadConfig(bytes.NewReader(orgCfg.NetworkConfig))
+	} else {
+		v.SetConfigFile(orgCfg.NetworkConfigPath)
+		err = v.ReadInConfig()
+	}
+	if err != nil {
+		report.add(SeverityError, orgCfg.Name, networkConfigPath, "failed to load connection profile: %s", err.Error())
+		return
+	}
+	profile := &connectionProfile{
+		clientOrg: orgCfg.Name,
+		report:    report,
+		client:    toStringMap(v.Get("client")),
+		channels:  toStringMap(v.Get("channels")),
+		orgs:      toStringMap(v.Get("organizations")),
+		peers:     toStringMap(v.Get("peers")),
+		orderers:  toStringMap(v.Get("orderers")),
+		cas:       toStringMap(v.Get("certificateauthorities")),
+	}
+	profile.validateOrganizations()
+	profile.validateChannels()
+	profile.validateEndpoints("peers", profile.peers)
+	profile.validateEndpoints("orderers", profile.orderers)
+	profile.validateEndpoints("certificateauthorities", profile.cas)
+	profile.validateIdentities(orgCfg)
+}
+
+func (p *connectionProfile) errorf(field string, format string, args ...interface{}) {
+	p.report.add(SeverityError, p.clientOrg, field, format, args...)
+}
+
+func (p *connectionProfile) warnf(field string, format string, args ...interface{}) {
+	p.report.add(SeverityWarning, p.clientOrg, field, format, args...)
+}
+
+func (p *connectionProfile) validateOrganizations() {
+	clientOrgID := strings.ToLower(stringOf(p.client["organization"]))
+	if clientOrgID == "" {
+		p.errorf("client.organization", "is required")
+	} else if _, found := p.orgs[clientOrgID]; !found {
+		p.errorf("client.organization", "organization %s is not defined under organizations", clientOrgID)
+	}
+	for orgID, o := range p.orgs {
+		org := toStringMap(o)
+		field := "organizations." + orgID
+		if stringOf(org["mspid"]) == "" {
+			p.errorf(field+".mspid", "is required")
+		}
+		for _, peer := range stringsOf(org["peers"]) {
+			if _, found := p.peers[strings.ToLower(peer)]; !found {
+				p.errorf(field+".peers", "peer %s is not defined under peers", peer)
+			}
+		}
+		for _, ca := range stringsOf(org["certificateauthorities"]) {
+			if _, found := p.cas[strings.ToLower(ca)]; !found {
+				p.errorf(field+".certificateauthorities", "certificate authority %s is not defined under certificateAuthorities", ca)
+			}
+		}
+	}
+}
+
+func (p *connectionProfile) validateChannels() {
+	for channelID, c := range p.channels {
+		channel := toStringMap(c)
+		field := "channels." + channelID
+		for peer := range toStringMap(channel["peers"]) {
+			if _, found := p.peers[peer]; !found {
+				p.errorf(field+".peers", "peer %s is not defined under peers", peer)
+			}
+		}
+		for _, orderer := range stringsOf(channel["orderers"]) {
+			if _, found := p.orderers[strings.ToLower(orderer)]; !found {
+				p.errorf(field+".orderers", "orderer %s is not defined under orderers", orderer)
+			}
+		}
+	}
+}
+
+func (p *connectionProfile) validateEndpoints(section string, endpoints map[string]interface{}) {
+	for name, e := range endpoints {
+		endpoint := toStringMap(e)
+		field := section + "." + name
+		if stringOf(endpoint["url"]) == "" {
+			p.errorf(field+".url", "is required")
+		}
+		tlsCACerts := toStringMap(endpoint["tlscacerts"])
+		if len(tlsCACerts) == 0 {
+			p.warnf(field+".tlscacerts", "is not set, TLS connections will rely on the system certificate pool")
+			continue
+		}
+		if pemCert := stringOf(tlsCACerts["pem"]); pemCert != "" {
+			if err := parseCertificates([]byte(pemCert)); err != nil {
+				p.errorf(field+".tlscacerts.pem", "%s", err.Error())
+			}
+		}
+		//CA TLS certs may be a comma separated list of paths
+		for _, path := range strings.Split(stringOf(tlsCACerts["path"]), ",") {
+			if strings.TrimSpace(path) == "" {
+				continue
+			}
+			if err := parseCertificateFile(substPath(path)); err != nil {
+				p.errorf(field+".tlscacerts.path", "%s", err.Error())
+			}
+		}
+	}
+}
+
+//validateIdentities checks that the user and admins of the client org, and the admins of the other orgs, resolve
+func (p *connectionProfile) validateIdentities(orgCfg *ClientOrgConfig) {
+	cryptoConfigPath := substPath(stringOf(toStringMap(p.client["cryptoconfig"])["path"]))
+	if cryptoConfigPath != "" {
+		if _, err := os.Stat(cryptoConfigPath); err != nil {
+			p.errorf("client.cryptoconfig.path", "%s", err.Error())
+		}
+	}
+	credentialStorePath := substPath(stringOf(toStringMap(p.client["credentialstore"])["path"]))
+	clientOrgID := strings.ToLower(stringOf(p.client["organization"]))
+	adminsByOrg := orgCfg.adminsByOrg()
+	for orgID := range p.orgs {
+		if strings.Contains(orgID, "orderer") {
+			continue
+		}
+		severity := SeverityWarning
+		usernames := adminsByOrg[orgID]
+		if len(usernames) == 0 {
+			usernames = []string{adminUser}
+		}
+		//The identities of a client org without user are not loaded, see ClientOrgConfig.User
+		if orgID == clientOrgID && orgCfg.User != "" {
+			severity = SeverityError
+			usernames = append([]string{orgCfg.User}, usernames...)
+		}
+		for _, username := range usernames {
+			field := "organizations." + orgID + ".users." + username
+			if err := p.resolveIdentity(orgID, username, cryptoConfigPath, credentialStorePath); err != nil {
+				p.report.add(severity, p.clientOrg, field, "identity does not resolve: %s", err.Error())
+			}
+		}
+	}
+}
+
+//resolveIdentity looks the enrollment certificate of the user up in the embedded users, the credential store and the org crypto path
+func (p *connectionProfile) resolveIdentity(orgID string, username string, cryptoConfigPath string, credentialStorePath string) error {
+	org := toStringMap(p.orgs[orgID])
+	user := toStringMap(toStringMap(org["users"])[strings.ToLower(username)])
+	if len(user) > 0 {
+		cert := toStringMap(user["cert"])
+		if pemCert := stringOf(cert["pem"]); pemCert != "" {
+			return parseCertificates([]byte(pemCert))
+		}
+		return parseCertificateFile(substPath(stringOf(cert["path"])))
+	}
+	if credentialStorePath != "" {
+		storedCert := filepath.Join(credentialStorePath, username+"@"+stringOf(org["mspid"])+"-cert.pem")
+		if _, err := os.Stat(storedCert); err == nil {
+			return parseCertificateFile(storedCert)
+		}
+	}
+	cryptoPath := stringOf(org["cryptopath"])
+	if cryptoPath == "" {
+		return fmt.Errorf("no embedded user, stored enrollment or cryptoPath found for %s", username)
+	}
+	cryptoPath = strings.Replace(cryptoPath, "{username}", username, -1)
+	cryptoPath = strings.Replace(cryptoPath, "{userName}", username, -1)
+	cryptoPath = substPath(cryptoPath)
+	if !filepath.IsAbs(cryptoPath) {
+		cryptoPath = filepath.Join(cryptoConfigPath, cryptoPath)
+	}
+	certs, err := filepath.Glob(filepath.Join(cryptoPath, "signcerts", "*.pem"))
+	if err != nil || len(certs) == 0 {
+		return fmt.Errorf("no certificate found in %s", filepath.Join(cryptoPath, "signcerts"))
+	}
+	keys, err := ioutil.ReadDir(filepath.Join(cryptoPath, "keystore"))
+	if err != nil || len(keys) == 0 {
+		return fmt.Errorf("no private key found in %s", filepath.Join(cryptoPath, "keystore"))
+	}
+	return parseCertificateFile(certs[0])
+}
+
+func parseCertificateFile(path string) error {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := parseCertificates(raw); err != nil {
+		return fmt.Errorf("%s: %s", path, err.Error())
+	}
+	return nil
+}
+
+func parseCertificates(raw []byte) error {
+	found := false
+	for {
+		var block *pem.Block
+		block, raw = pem.Decode(raw)
+		if block == nil {
+			break
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return fmt.Errorf("invalid certificate: %s", err.Error())
+		}
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("no PEM encoded certificate found")
+	}
+	return nil
+}
+
+//substPath expands the environment variables of a connection profile path, e.g. ${GOPATH}
+func substPath(path string) string {
+	return os.ExpandEnv(strings.TrimSpace(path))
+}
+
+func toStringMap(val interface{}) map[string]interface{} {
+	switch m := val.(type) {
+	case map[string]interface{}:
+		lower := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			lower[strings.ToLower(k)] = v
+		}
+		return lower
+	case map[interface{}]interface{}:
+		lower := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			lower[strings.ToLower(fmt.Sprint(k))] = v
+		}
+		return lower
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func stringOf(val interface{}) string {
+	s, _ := val.(string)
+	return s
+}
+
+func stringsOf(val interface{}) []string {
+	switch list := val.(type) {
+	case []string:
+		return list
+	case []interface{}:
+		var s []string
+		for _, item := range list {
+			s = append(s, fmt.Sprint(item))
+		}
+		return s
+	default:
+		return nil
+	}
+}
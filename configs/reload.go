@@ -0,0 +1,261 @@
+package configs
+
+import (
+	"crypto/sha256"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+)
+
+//reloadDelay debounces the bursts of file system events produced by a single config update
+const reloadDelay = 500 * time.Millisecond
+
+//newOrgNetworkConfig builds the network config of a client org and records what it was built from
+func newOrgNetworkConfig(orgCfg *ClientOrgConfig) (*networkConfig, error) {
+	digest, err := profileDigest(orgCfg)
+	if err != nil {
+		return nil, errors.Errorf("Network config initialization failed with error: %s", err.Error())
+	}
+	netCfg, err := initNetworkConfig(orgCfg.networkConfigProvider(), orgCfg.User, orgCfg.adminsByOrg())
+	if err != nil {
+		return nil, err
+	}
+	netCfg.orgCfg = *orgCfg
+	netCfg.profileDigest = digest
+	return netCfg, nil
+}
+
+func profileDigest(orgCfg *ClientOrgConfig) ([sha256.Size]byte, error) {
+	if len(orgCfg.NetworkConfig) > 0 {
+		return sha256.Sum256(orgCfg.NetworkConfig), nil
+	}
+	raw, err := ioutil.ReadFile(orgCfg.NetworkConfigPath)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(raw), nil
+}
+
+//changed reports whether the network config must be rebuilt for the client org app config
+func (netCfg *networkConfig) changed(orgCfg *ClientOrgConfig) bool {
+	if !reflect.DeepEqual(netCfg.orgCfg, *orgCfg) {
+		return true
+	}
+	digest, err := profileDigest(orgCfg)
+	return err != nil || digest != netCfg.profileDigest
+}
+
+func (copts *configOptionService) root() *configOptionService {
+	if copts.parent != nil {
+		return copts.parent
+	}
+	return copts
+}
+
+//Pin returns a view of the config options in which the network config of the client org stays the one current at the call,
+//whatever the reloads, until the returned release function is called. The SDK of a replaced network config is closed once released.
+//It returns an error if the client org is not configured, the check and the pin being atomic with respect to reloads.
+func (copts *configOptionService) Pin(clientOrgID string) (ConfigOptions, func(), error) {
+	root := copts.root()
+	root.mutex.RLock()
+	defer root.mutex.RUnlock()
+	pinned, found := root.networkCfgMap[clientOrgID]
+	if !found {
+		return nil, nil, errors.Errorf("Client org %s is not configured", clientOrgID)
+	}
+	view := new(configOptionService)
+	view.parent = root
+	view.appCfgMap = make(map[string]*ClientOrgConfig, len(root.appCfgMap))
+	for org, appCfg := range root.appCfgMap {
+		view.appCfgMap[org] = appCfg
+	}
+	view.networkCfgMap = make(map[string]*networkConfig, len(root.networkCfgMap))
+	for org, netCfg := range root.networkCfgMap {
+		view.networkCfgMap[org] = netCfg
+	}
+	pinned.acquire()
+	var once sync.Once
+	return view, func() { once.Do(pinned.release) }, nil
+}
+
+//IsCurrent reports whether the network config of the client org is still the current one. It is always true unless called on a pinned view.
+func (copts *configOptionService) IsCurrent(clientOrgID string) bool {
+	root := copts.root()
+	if root == copts {
+		return true
+	}
+	return copts.networkCfg(clientOrgID) == root.networkCfg(clientOrgID)
+}
+
+//Reload reloads the app config and rebuilds the network config and SDK of every client org whose app config or
+//connection profile changed. The config options are swapped atomically; pinned network configs remain usable until released.
+//On error the current config is kept.
+func (copts *configOptionService) Reload() error {
+	root := copts.root()
+	root.reloadMutex.Lock()
+	defer root.reloadMutex.Unlock()
+	appCfg, err := initAppConfig(root.source, DefaultEnvPrefix)
+	if err != nil {
+		return errors.WithMessage(err, "Reload of App Config failed with the error")
+	}
+	root.mutex.RLock()
+	current := root.networkCfgMap
+	root.mutex.RUnlock()
+
+	appCfgMap := make(map[string]*ClientOrgConfig)
+	networkCfgMap := make(map[string]*networkConfig)
+	var built, retired []*networkConfig
+	for i := range appCfg.ClientOrgs {
+		orgCfg := &appCfg.ClientOrgs[i]
+		appCfgMap[orgCfg.Name] = orgCfg
+		old, found := current[orgCfg.Name]
+		if found && !old.changed(orgCfg) {
+			networkCfgMap[orgCfg.Name] = old
+			continue
+		}
+		netCfg, err := newOrgNetworkConfig(orgCfg)
+		if err != nil {
+			for _, b := range built {
+				b.retire()
+			}
+			return errors.Errorf("Reload of Network Config of client org %s failed with the error: %s", orgCfg.Name, err.Error())
+		}
+		if found {
+			//Identities registered at runtime outlive the reload
+			netCfg.identities = old.identities
+			retired = append(retired, old)
+		}
+		built = append(built, netCfg)
+		networkCfgMap[orgCfg.Name] = netCfg
+	}
+	for org, old := range current {
+		if _, found := networkCfgMap[org]; !found {
+			retired = append(retired, old)
+		}
+	}
+
+	root.mutex.Lock()
+	root.appCfgMap = appCfgMap
+	root.networkCfgMap = networkCfgMap
+	root.mutex.Unlock()
+	for _, old := range retired {
+		old.retire()
+	}
+	return nil
+}
+
+//Close stops watching the config and closes the SDK of every client org once released. Closing a pinned view is a no-op.
+func (copts *configOptionService) Close() {
+	if copts.parent != nil {
+		return
+	}
+	copts.mutex.Lock()
+	watcher := copts.watcher
+	copts.watcher = nil
+	networkCfgMap := copts.networkCfgMap
+	copts.networkCfgMap = make(map[string]*networkConfig)
+	copts.mutex.Unlock()
+	if watcher != nil {
+		watcher.stop()
+	}
+	for _, netCfg := range networkCfgMap {
+		netCfg.retire()
+	}
+}
+
+//watchedFiles returns the app config file and the connection profile files of the client orgs
+func (copts *configOptionService) watchedFiles() map[string]bool {
+	copts.mutex.RLock()
+	defer copts.mutex.RUnlock()
+	files := make(map[string]bool)
+	if copts.appConfigFile != "" {
+		files[filepath.Clean(copts.appConfigFile)] = true
+	}
+	for _, appCfg := range copts.appCfgMap {
+		if len(appCfg.NetworkConfig) == 0 && appCfg.NetworkConfigPath != "" {
+			files[filepath.Clean(appCfg.NetworkConfigPath)] = true
+		}
+	}
+	return files
+}
+
+//configWatcher reloads the config options when the app config or a connection profile file changes
+type configWatcher struct {
+	fsWatcher *fsnotify.Watcher
+	done      chan struct{}
+	stopped   chan struct{}
+}
+
+//Watch watches the app config file and the connection profile files and reloads the config options when they change.
+//onReload, if set, is called with the outcome of each reload.
+func (copts *configOptionService) Watch(onReload func(err error)) error {
+	root := copts.root()
+	root.mutex.Lock()
+	defer root.mutex.Unlock()
+	if root.watcher != nil {
+		return errors.New("config is already watched")
+	}
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.Errorf("failed to create config watcher: %s", err.Error())
+	}
+	watcher := &configWatcher{fsWatcher: fsWatcher, done: make(chan struct{}), stopped: make(chan struct{})}
+	root.watcher = watcher
+	go watcher.run(root, onReload)
+	return nil
+}
+
+func (w *configWatcher) run(copts *configOptionService, onReload func(err error)) {
+	defer close(w.stopped)
+	files := w.watch(copts)
+	timer := time.NewTimer(reloadDelay)
+	timer.Stop()
+	for {
+		select {
+		case event, ok := <-w.fsWatcher.Events:
+			if !ok {
+				return
+			}
+			if files[filepath.Clean(event.Name)] && event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove) != 0 {
+				timer.Reset(reloadDelay)
+			}
+		case err, ok := <-w.fsWatcher.Errors:
+			if !ok {
+				return
+			}
+			if onReload != nil {
+				onReload(errors.Errorf("config watcher error: %s", err.Error()))
+			}
+		case <-timer.C:
+			err := copts.Reload()
+			if onReload != nil {
+				onReload(err)
+			}
+			//Connection profiles may have been added or moved
+			files = w.watch(copts)
+		case <-w.done:
+			return
+		}
+	}
+}
+
+//watch watches the directories of the watched files, since editors commonly replace files rather than write them
+func (w *configWatcher) watch(copts *configOptionService) map[string]bool {
+	files := copts.watchedFiles()
+	for file := range files {
+		//A directory that cannot be watched yet is retried after the next reload
+		w.fsWatcher.Add(filepath.Dir(file))
+	}
+	return files
+}
+
+func (w *configWatcher) stop() {
+	close(w.done)
+	w.fsWatcher.Close()
+	<-w.stopped
+}
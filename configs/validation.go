@@ -0,0 +1,81 @@
+package configs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//ValidationProblem is a single problem found in the app config
+type ValidationProblem struct {
+	//Field is the path of the offending key, e.g. org1.networkconfigpath
+	Field   string
+	Message string
+}
+
+func (p ValidationProblem) String() string {
+	return p.Field + ": " + p.Message
+}
+
+//ValidationError reports every problem found in the app config
+type ValidationError struct {
+	Problems []ValidationProblem
+}
+
+func (e *ValidationError) Error() string {
+	problems := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		problems[i] = p.String()
+	}
+	return fmt.Sprintf("invalid app config, %d problem(s) found: %s", len(problems), strings.Join(problems, "; "))
+}
+
+func (e *ValidationError) add(field string, message string) {
+	e.Problems = append(e.Problems, ValidationProblem{Field: field, Message: message})
+}
+
+func (e *ValidationError) merge(err error) {
+	if other, ok := err.(*ValidationError); ok && other != nil {
+		e.Problems = append(e.Problems, other.Problems...)
+	}
+}
+
+//errorOrNil returns the error with the problems sorted by field, nil if no problem was found
+func (e *ValidationError) errorOrNil() error {
+	if len(e.Problems) == 0 {
+		return nil
+	}
+	sort.SliceStable(e.Problems, func(i, j int) bool { return e.Problems[i].Field < e.Problems[j].Field })
+	return e
+}
+
+//ValidateAppConfig checks that the app config defines at least one client org and the required values of each org.
+//It returns a *ValidationError listing every problem found, nil if the app config is valid.
+func ValidateAppConfig(appCfg AppConfig) error {
+	problems := new(ValidationError)
+	if len(appCfg.ClientOrgs) == 0 {
+		problems.add(clientOrgs, "at least one client org is required")
+	}
+	names := make(map[string]bool)
+	for i, org := range appCfg.ClientOrgs {
+		if org.Name == "" {
+			problems.add(fmt.Sprintf("%s[%d]", clientOrgs, i), "client org name is required")
+			continue
+		}
+		if names[strings.ToLower(org.Name)] {
+			problems.add(fmt.Sprintf("%s[%d]", clientOrgs, i), "client org "+org.Name+" is listed more than once")
+		}
+		names[strings.ToLower(org.Name)] = true
+		if org.NetworkConfigPath == "" && len(org.NetworkConfig) == 0 {
+			problems.add(org.Name+"."+networkConfigPath, "is required unless "+org.Name+"."+networkConfigKey+" is set")
+		}
+		for adminOrg, adminNames := range org.Admins {
+			for j, name := range adminNames {
+				if name == "" {
+					problems.add(fmt.Sprintf("%s.%s.%s[%d]", org.Name, admins, adminOrg, j), "admin name is required")
+				}
+			}
+		}
+	}
+	return problems.errorOrNil()
+}
@@ -1,14 +1,20 @@
 package fabricsdk
 
 import (
+	"context"
+	"io"
+
 	"dendrix.io/fabricsdk/chaincode"
 	"dendrix.io/fabricsdk/configs"
 	"dendrix.io/fabricsdk/providers"
+	"github.com/pkg/errors"
 )
 
 type fabricNetwork struct {
 	cfgOptions     configs.ConfigOptions
 	clientProvider providers.FabricNetworkClientProvider
+	identity       string
+	metadataCache  *chaincode.MetadataCache
 }
 
 //FabricNetwork defines the available fabric network methods
@@ -18,77 +24,403 @@ type FabricNetwork interface {
 	ChaincodeUpgradeClient(clientOrgID string, channelID string, chaincodeID string, chaincodeVersion string, chaincodePath string, policy string, args [][]byte, collectionConfigFile string) (chaincode.ChaincodeClient, error)
 	ChaincodeExecutionClient(clientOrgID string, channelID string, chaincodeID string, fn string, args [][]byte) (chaincode.ChaincodeClient, error)
 	ChaincodeQueryClient(clientOrgID string, channelID string, chaincodeID string, fn string, args [][]byte) (chaincode.ChaincodeClient, error)
+	RegisterIdentity(clientOrgID string, name string, certPEM []byte, keyPEM []byte) error
+	WithIdentity(name string) FabricNetwork
+	CertMonitor(cfg configs.CertMonitorConfig) *configs.CertMonitor
+	Reload() error
+	WatchConfig(onReload func(err error)) error
+	Close()
+	Install(clientOrgID string, req chaincode.InstallRequest, opts ...chaincode.Option) (chaincode.ChaincodeClient, error)
+	Instantiate(clientOrgID string, req chaincode.DeployRequest, opts ...chaincode.Option) (chaincode.ChaincodeClient, error)
+	Upgrade(clientOrgID string, req chaincode.DeployRequest, opts ...chaincode.Option) (chaincode.ChaincodeClient, error)
+	Execute(clientOrgID string, req chaincode.InvokeRequest, opts ...chaincode.Option) (chaincode.ChaincodeClient, error)
+	ExecuteAsync(clientOrgID string, req chaincode.InvokeRequest, opts ...chaincode.Option) (*chaincode.Commit, error)
+	Simulate(clientOrgID string, req chaincode.InvokeRequest, opts ...chaincode.Option) (*chaincode.Simulation, error)
+	OfflineClient(clientOrgID string, channelID string, signer chaincode.Signer, opts ...chaincode.Option) (*chaincode.OfflineClient, error)
+	TransactionID(clientOrgID string, nonce []byte, opts ...chaincode.Option) (string, error)
+	InspectTransaction(clientOrgID string, channelID string, txID string) (*chaincode.Transaction, error)
+	Query(clientOrgID string, req chaincode.InvokeRequest, opts ...chaincode.Option) (chaincode.ChaincodeClient, error)
+	QueryIterator(ctx context.Context, clientOrgID string, query chaincode.PageQuery, opts ...chaincode.Option) (*chaincode.QueryIterator, error)
+	Contract(clientOrgID string, channelID string, chaincodeID string, opts ...chaincode.Option) (*chaincode.Contract, error)
+	Metadata(clientOrgID string, channelID string, chaincodeID string) (*chaincode.ChaincodeMetadata, error)
+	MetadataCache() *chaincode.MetadataCache
 }
 
-var fabNetwork *fabricNetwork
-
-//NewFabricNetwork returns an instance of the fabric network
+//NewFabricNetwork returns a new instance of the fabric network
 func NewFabricNetwork(configPath string) (FabricNetwork, error) {
-	if err := initialize(configPath); err != nil {
-		return nil, err
-	}
-	return fabNetwork, nil
+	return newFabricNetwork(configs.NewConfigOptions(configPath))
+}
+
+//NewFabricNetworkFromReader returns an instance of the fabric network configured by the app config of the config type (json, yaml...) read from r
+func NewFabricNetworkFromReader(r io.Reader, configType string) (FabricNetwork, error) {
+	return newFabricNetwork(configs.NewConfigOptionsFromReader(r, configType))
+}
+
+//NewFabricNetworkFromBytes returns an instance of the fabric network configured by the raw app config of the config type (json, yaml...)
+func NewFabricNetworkFromBytes(raw []byte, configType string) (FabricNetwork, error) {
+	return newFabricNetwork(configs.NewConfigOptionsFromBytes(raw, configType))
+}
+
+//NewFabricNetworkFromConfig returns an instance of the fabric network configured by the app config
+func NewFabricNetworkFromConfig(appCfg configs.AppConfig) (FabricNetwork, error) {
+	return newFabricNetwork(configs.NewConfigOptionsFromAppConfig(appCfg))
 }
 
-func initialize(configPath string) error {
+//Validate runs the preflight validation of the fabricApp.json in the config path and of the connection profiles it references
+func Validate(configPath string) *configs.Report {
+	return configs.Validate(configPath)
+}
+
+//newFabricNetwork returns a fabric network owning the config options.
+//Every fabric network has its own SDKs and sessions so that several networks can be used from the same process.
+func newFabricNetwork(cfgOptions configs.ConfigOptions, err error) (FabricNetwork, error) {
 	//Get Network config options and store in memory
 	//
-	cfgOptions, err := configs.NewConfigOptions(configPath)
 	if err != nil {
-		return err
-	}
-	if fabNetwork == nil {
-		fabNetwork = new(fabricNetwork)
+		return nil, err
 	}
+	fabNetwork := new(fabricNetwork)
 	fabNetwork.cfgOptions = cfgOptions
-	return nil
+	fabNetwork.metadataCache = chaincode.NewMetadataCache()
+	return fabNetwork, nil
 }
 
 func (fN *fabricNetwork) ChaincodeInstallClient(clientOrgID string, chaincodeID string, chaincodeVersion string, chaincodePath string) (chaincode.ChaincodeClient, error) {
+	req := chaincode.InstallRequest{
+		ChaincodeID:      chaincodeID,
+		ChaincodeVersion: chaincodeVersion,
+		ChaincodePath:    chaincodePath,
+	}
+	return fN.Install(clientOrgID, req)
+}
+
+func (fN *fabricNetwork) ChaincodeInstantiateClient(clientOrgID string, channelID string, chaincodeID string, chaincodeVersion string, chaincodePath string, policy string, args [][]byte, collectionConfigFile string) (chaincode.ChaincodeClient, error) {
+	req := chaincode.DeployRequest{
+		ChannelID:        channelID,
+		ChaincodeID:      chaincodeID,
+		ChaincodeVersion: chaincodeVersion,
+		ChaincodePath:    chaincodePath,
+		Args:             args,
+	}
+	return fN.Instantiate(clientOrgID, req, chaincode.WithPolicy(policy), chaincode.WithCollectionConfigFile(collectionConfigFile))
+}
+
+func (fN *fabricNetwork) ChaincodeUpgradeClient(clientOrgID string, channelID string, chaincodeID string, chaincodeVersion string, chaincodePath string, policy string, args [][]byte, collectionConfigFile string) (chaincode.ChaincodeClient, error) {
+	req := chaincode.DeployRequest{
+		ChannelID:        channelID,
+		ChaincodeID:      chaincodeID,
+		ChaincodeVersion: chaincodeVersion,
+		ChaincodePath:    chaincodePath,
+		Args:             args,
+	}
+	return fN.Upgrade(clientOrgID, req, chaincode.WithPolicy(policy), chaincode.WithCollectionConfigFile(collectionConfigFile))
+}
+
+func (fN *fabricNetwork) ChaincodeExecutionClient(clientOrgID string, channelID string, chaincodeID string, fn string, args [][]byte) (chaincode.ChaincodeClient, error) {
+	req := chaincode.InvokeRequest{
+		ChannelID:   channelID,
+		ChaincodeID: chaincodeID,
+		Fcn:         fn,
+		Args:        args,
+	}
+	return fN.Execute(clientOrgID, req)
+}
+
+func (fN *fabricNetwork) ChaincodeQueryClient(clientOrgID string, channelID string, chaincodeID string, fn string, args [][]byte) (chaincode.ChaincodeClient, error) {
+	req := chaincode.InvokeRequest{
+		ChannelID:   channelID,
+		ChaincodeID: chaincodeID,
+		Fcn:         fn,
+		Args:        args,
+	}
+	return fN.Query(clientOrgID, req)
+}
+
+//Install returns the client installing the requested chaincode on the network peers
+func (fN *fabricNetwork) Install(clientOrgID string, req chaincode.InstallRequest, opts ...chaincode.Option) (chaincode.ChaincodeClient, error) {
 	//Get the Client provider
-	fNClientProvider := providers.NewFabricNetworkClientProvider(clientOrgID, fN.cfgOptions)
+	fNClientProvider, err := fN.newClientProviderWithOptions(clientOrgID, opts)
+	if err != nil {
+		return nil, err
+	}
 	//Get the chaincode client
-	client, err := chaincode.NewInstallClient(fNClientProvider, chaincodeID, chaincodeVersion, chaincodePath)
+	client, err := chaincode.NewInstallClientFromRequest(fNClientProvider, req, opts...)
 	if err != nil {
+		fNClientProvider.CloseSDK()
 		return nil, err
 	}
 	return client, nil
 }
 
-func (fN *fabricNetwork) ChaincodeInstantiateClient(clientOrgID string, channelID string, chaincodeID string, chaincodeVersion string, chaincodePath string, policy string, args [][]byte, collectionConfigFile string) (chaincode.ChaincodeClient, error) {
+//Instantiate returns the client instantiating the requested chaincode on the channel
+func (fN *fabricNetwork) Instantiate(clientOrgID string, req chaincode.DeployRequest, opts ...chaincode.Option) (chaincode.ChaincodeClient, error) {
 	//Get the Client provider
-	fNClientProvider := providers.NewFabricNetworkClientProvider(clientOrgID, fN.cfgOptions)
+	fNClientProvider, err := fN.newClientProviderWithOptions(clientOrgID, opts)
+	if err != nil {
+		return nil, err
+	}
 	//Get the chaincode client
-	client, err := chaincode.NewInstantiateClient(fNClientProvider, channelID, chaincodeID, chaincodeVersion, chaincodePath, policy, args, collectionConfigFile)
+	client, err := chaincode.NewInstantiateClientFromRequest(fNClientProvider, req, opts...)
 	if err != nil {
+		fNClientProvider.CloseSDK()
 		return nil, err
 	}
 	return client, nil
 }
 
-func (fN *fabricNetwork) ChaincodeUpgradeClient(clientOrgID string, channelID string, chaincodeID string, chaincodeVersion string, chaincodePath string, policy string, args [][]byte, collectionConfigFile string) (chaincode.ChaincodeClient, error) {
+//Upgrade returns the client upgrading the requested chaincode on the channel
+func (fN *fabricNetwork) Upgrade(clientOrgID string, req chaincode.DeployRequest, opts ...chaincode.Option) (chaincode.ChaincodeClient, error) {
 	//Get the Client provider
-	fNClientProvider := providers.NewFabricNetworkClientProvider(clientOrgID, fN.cfgOptions)
+	fNClientProvider, err := fN.newClientProviderWithOptions(clientOrgID, opts)
+	if err != nil {
+		return nil, err
+	}
 	//Get the chaincode client
-	client, err := chaincode.NewUpgradeClient(fNClientProvider, channelID, chaincodeID, chaincodeVersion, chaincodePath, policy, args, collectionConfigFile)
+	client, err := chaincode.NewUpgradeClientFromRequest(fNClientProvider, req, opts...)
 	if err != nil {
+		fNClientProvider.CloseSDK()
 		return nil, err
 	}
 	return client, nil
 }
 
-func (fN *fabricNetwork) ChaincodeExecutionClient(clientOrgID string, channelID string, chaincodeID string, fn string, args [][]byte) (chaincode.ChaincodeClient, error) {
+//Execute returns the client executing the requested chaincode function
+func (fN *fabricNetwork) Execute(clientOrgID string, req chaincode.InvokeRequest, opts ...chaincode.Option) (chaincode.ChaincodeClient, error) {
 	//Get the Client provider
-	fNClientProvider := providers.NewFabricNetworkClientProvider(clientOrgID, fN.cfgOptions)
+	fNClientProvider, err := fN.newClientProviderWithOptions(clientOrgID, opts)
+	if err != nil {
+		return nil, err
+	}
 	//Get the chaincode client
-	client := chaincode.NewExecuteClient(fNClientProvider, channelID, chaincodeID, fn, args)
+	client, err := chaincode.NewExecuteClientFromRequest(fNClientProvider, req, opts...)
+	if err != nil {
+		fNClientProvider.CloseSDK()
+		return nil, err
+	}
 	return client, nil
 }
 
-func (fN *fabricNetwork) ChaincodeQueryClient(clientOrgID string, channelID string, chaincodeID string, fn string, args [][]byte) (chaincode.ChaincodeClient, error) {
+//ExecuteAsync submits the requested chaincode function and returns the pending commit status of the transaction
+func (fN *fabricNetwork) ExecuteAsync(clientOrgID string, req chaincode.InvokeRequest, opts ...chaincode.Option) (*chaincode.Commit, error) {
+	if err := validateOptions(chaincode.ExecuteAsyncRequestType, opts); err != nil {
+		return nil, err
+	}
+	//Get the Client provider
+	fNClientProvider, err := fN.newClientProviderWithOptions(clientOrgID, opts)
+	if err != nil {
+		return nil, err
+	}
+	//Get the chaincode client
+	client, err := chaincode.NewExecuteClientFromRequest(fNClientProvider, req, opts...)
+	if err != nil {
+		fNClientProvider.CloseSDK()
+		return nil, err
+	}
+	commit, err := client.InvokeAsync()
+	if err != nil {
+		client.Terminate()
+		return nil, err
+	}
+	//The client is terminated once the commit event is received
+	go func() {
+		<-commit.Done()
+		client.Terminate()
+	}()
+	return commit, nil
+}
+
+//Simulate endorses the requested chaincode function without sending the transaction to the orderer
+func (fN *fabricNetwork) Simulate(clientOrgID string, req chaincode.InvokeRequest, opts ...chaincode.Option) (*chaincode.Simulation, error) {
+	if err := validateOptions(chaincode.SimulateRequestType, opts); err != nil {
+		return nil, err
+	}
 	//Get the Client provider
-	fNClientProvider := providers.NewFabricNetworkClientProvider(clientOrgID, fN.cfgOptions)
+	fNClientProvider, err := fN.newClientProviderWithOptions(clientOrgID, opts)
+	if err != nil {
+		return nil, err
+	}
 	//Get the chaincode client
-	client := chaincode.NewQueryClient(fNClientProvider, channelID, chaincodeID, fn, args)
+	client, err := chaincode.NewExecuteClientFromRequest(fNClientProvider, req, opts...)
+	if err != nil {
+		fNClientProvider.CloseSDK()
+		return nil, err
+	}
+	defer client.Terminate()
+	return client.Simulate()
+}
+
+//OfflineClient returns the client executing chaincode functions of the channel in steps, signed by an external signer. Close it when no longer needed.
+//The client org may have neither user nor keystore configured, the channel context being created for the identity of the signer.
+func (fN *fabricNetwork) OfflineClient(clientOrgID string, channelID string, signer chaincode.Signer, opts ...chaincode.Option) (*chaincode.OfflineClient, error) {
+	//Get the Client provider
+	fNClientProvider, err := fN.newClientProviderWithOptions(clientOrgID, opts)
+	if err != nil {
+		return nil, err
+	}
+	client, err := chaincode.NewOfflineClient(fNClientProvider, channelID, signer, opts...)
+	if err != nil {
+		fNClientProvider.CloseSDK()
+		return nil, err
+	}
+	return client, nil
+}
+
+//TransactionID returns the ID of the transaction with the nonce executed by the identity of the options, to pass with chaincode.WithNonce.
+//The ID is computed offline, see chaincode.ComputeTransactionID.
+func (fN *fabricNetwork) TransactionID(clientOrgID string, nonce []byte, opts ...chaincode.Option) (string, error) {
+	if len(nonce) == 0 {
+		return "", errors.New("nonce is not set")
+	}
+	//Get the Client provider
+	fNClientProvider, err := fN.newClientProviderWithOptions(clientOrgID, opts)
+	if err != nil {
+		return "", err
+	}
+	defer fNClientProvider.CloseSDK()
+	user := fNClientProvider.ClientUser()
+	if user == nil {
+		return "", errors.Errorf("client org %s has no user configured", clientOrgID)
+	}
+	creator, err := user.Serialize()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to serialize the identity signing the transaction")
+	}
+	return chaincode.ComputeTransactionID(nonce, creator), nil
+}
+
+//InspectTransaction fetches the committed transaction of the channel and decodes its read/write sets
+func (fN *fabricNetwork) InspectTransaction(clientOrgID string, channelID string, txID string) (*chaincode.Transaction, error) {
+	//Get the Client provider
+	fNClientProvider, err := fN.newClientProviderWithOptions(clientOrgID, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer fNClientProvider.CloseSDK()
+	return chaincode.InspectTransaction(fNClientProvider, channelID, txID)
+}
+
+//Query returns the client querying the requested chaincode function
+func (fN *fabricNetwork) Query(clientOrgID string, req chaincode.InvokeRequest, opts ...chaincode.Option) (chaincode.ChaincodeClient, error) {
+	//Get the Client provider
+	fNClientProvider, err := fN.newClientProviderWithOptions(clientOrgID, opts)
+	if err != nil {
+		return nil, err
+	}
+	//Get the chaincode client
+	client, err := chaincode.NewQueryClientFromRequest(fNClientProvider, req, opts...)
+	if err != nil {
+		fNClientProvider.CloseSDK()
+		return nil, err
+	}
 	return client, nil
 }
+
+//QueryIterator returns an iterator over the items of the paginated query. Close it when no longer needed.
+func (fN *fabricNetwork) QueryIterator(ctx context.Context, clientOrgID string, query chaincode.PageQuery, opts ...chaincode.Option) (*chaincode.QueryIterator, error) {
+	//Get the Client provider
+	fNClientProvider, err := fN.newClientProviderWithOptions(clientOrgID, opts)
+	if err != nil {
+		return nil, err
+	}
+	//Get the iterator
+	it, err := chaincode.NewQueryIterator(ctx, fNClientProvider, query, opts...)
+	if err != nil {
+		fNClientProvider.CloseSDK()
+		return nil, err
+	}
+	return it, nil
+}
+
+//Contract returns a long-lived handle on the chaincode of the channel for the client org. Close it when no longer needed.
+func (fN *fabricNetwork) Contract(clientOrgID string, channelID string, chaincodeID string, opts ...chaincode.Option) (*chaincode.Contract, error) {
+	newProvider := func() (providers.FabricNetworkClientProvider, error) {
+		return fN.newClientProviderWithOptions(clientOrgID, opts)
+	}
+	return chaincode.NewContract(newProvider, channelID, chaincodeID, opts...)
+}
+
+//Metadata returns the contract metadata of the chaincode, fetched once and cached
+func (fN *fabricNetwork) Metadata(clientOrgID string, channelID string, chaincodeID string) (*chaincode.ChaincodeMetadata, error) {
+	//Get the Client provider
+	fNClientProvider, err := fN.newClientProviderWithOptions(clientOrgID, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer fNClientProvider.CloseSDK()
+	return fN.metadataCache.Get(fNClientProvider, channelID, chaincodeID)
+}
+
+//MetadataCache returns the contract metadata cache of the network, to check requests with chaincode.WithMetadataValidation
+func (fN *fabricNetwork) MetadataCache() *chaincode.MetadataCache {
+	return fN.metadataCache
+}
+
+//RegisterIdentity registers a signing identity of the client org created from PEM certificate and key bytes
+func (fN *fabricNetwork) RegisterIdentity(clientOrgID string, name string, certPEM []byte, keyPEM []byte) error {
+	if name == "" {
+		return errors.New("identity name is not set")
+	}
+	_, err := fN.cfgOptions.RegisterIdentity(clientOrgID, name, certPEM, keyPEM)
+	return err
+}
+
+//WithIdentity returns a FabricNetwork whose clients sign with the registered identity instead of the configured user and admin
+func (fN *fabricNetwork) WithIdentity(name string) FabricNetwork {
+	network := *fN
+	network.identity = name
+	return &network
+}
+
+//CertMonitor returns a monitor of the certificate expiry of the identities loaded for the client orgs. Call Start to run it in the background
+func (fN *fabricNetwork) CertMonitor(cfg configs.CertMonitorConfig) *configs.CertMonitor {
+	return configs.NewCertMonitor(fN.cfgOptions, cfg)
+}
+
+//Reload reloads the app config and rebuilds the SDK of the client orgs whose app config or connection profile changed.
+//Clients created before the reload keep using the previous config until terminated.
+func (fN *fabricNetwork) Reload() error {
+	return fN.cfgOptions.Reload()
+}
+
+//WatchConfig reloads the config whenever the fabricApp.json or a connection profile file changes
+func (fN *fabricNetwork) WatchConfig(onReload func(err error)) error {
+	return fN.cfgOptions.Watch(onReload)
+}
+
+//Close stops watching the config and closes the SDKs once the clients using them are terminated
+func (fN *fabricNetwork) Close() {
+	fN.cfgOptions.Close()
+}
+
+//newClientProviderWithOptions returns the client provider signing with the identity set in the options, if any
+func (fN *fabricNetwork) newClientProviderWithOptions(clientOrgID string, opts []chaincode.Option) (providers.FabricNetworkClientProvider, error) {
+	options, err := chaincode.NewOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if options.Identity != "" {
+		return fN.newClientProviderWithIdentity(clientOrgID, options.Identity)
+	}
+	return fN.newClientProviderWithIdentity(clientOrgID, fN.identity)
+}
+
+func (fN *fabricNetwork) newClientProviderWithIdentity(clientOrgID string, name string) (providers.FabricNetworkClientProvider, error) {
+	if name == "" {
+		return providers.NewFabricNetworkClientProvider(clientOrgID, fN.cfgOptions)
+	}
+	identity, found := fN.cfgOptions.GetIdentity(clientOrgID, name)
+	if !found {
+		return nil, errors.Errorf("identity %s is not registered for client org %s", name, clientOrgID)
+	}
+	return providers.NewFabricNetworkClientProvider(clientOrgID, fN.cfgOptions, providers.WithUser(identity), providers.WithAdmin(identity))
+}
+
+//validateOptions rejects the options that do not apply to the request type.
+//The execute client validates them as execute requests, its asynchronous and simulate modes are validated beforehand.
+func validateOptions(reqType chaincode.RequestType, opts []chaincode.Option) error {
+	options, err := chaincode.NewOptions(opts...)
+	if err != nil {
+		return err
+	}
+	return options.Validate(reqType)
+}
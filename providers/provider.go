@@ -1,10 +1,13 @@
 package providers
 
 import (
-	"fmt"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
 
 	"dendrix.io/fabricsdk/configs"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/ledger"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/msp"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
@@ -27,54 +30,138 @@ type FabricNetworkClientProvider interface {
 	ResourceMgmtClientByAdmin() (*resmgmt.Client, error)
 	ResourceMgmtClientByUser(username string) (*resmgmt.Client, error)
 	ResourceMgmtClientByOrg(username string, orgID string) (*resmgmt.Client, error)
+	ResourceMgmtClientByOrgAdmin(orgID string) (*resmgmt.Client, error)
 	ClientOrgID() string
 	ParticipatingOrgs() []string
 	ClientOrgMSPID() string
 	ClientAdminUser() mspapi.SigningIdentity
+	ClientAdminUsers() []mspapi.SigningIdentity
+	OrgAdmins(orgID string) []string
 	ClientUser() mspapi.SigningIdentity
 	ClientOrgPeers() []fab.Peer
 	PeersByOrgID() map[string][]fab.Peer
 	CloseSDK()
 	ChannelClient(channelID string) (*channel.Client, error)
+	LedgerClient(channelID string) (*ledger.Client, error)
+	ChannelContext(channelID string) (context.ChannelProvider, error)
+	ChannelContextWithIdentity(channelID string, identity mspapi.SigningIdentity) (context.ChannelProvider, error)
+	Stale() bool
 }
 
 //clientProvider provides the fabric network context for a client organisation
 type clientProvider struct {
 	cfgOptions      configs.ConfigOptions
+	cfgOrgID        string
+	release         func()
 	sdk             *fabsdk.FabricSDK
 	peers           []fab.Peer
 	orgIDByPeer     map[string]string
 	orgsID          []string
 	orgMSPID        string
+	sessionMutex    sync.Mutex
 	sessions        map[string]context.ClientProvider
 	channelSessions map[string]context.ChannelProvider
+	channelClients  map[string]*channel.Client
+	ledgerClients   map[string]*ledger.Client
 	userName        string
 	user            mspapi.SigningIdentity
 	adminUser       mspapi.SigningIdentity
+	adminUsers      []mspapi.SigningIdentity
+	adminsByOrg     map[string][]string
+	adminOverridden bool
 	clientOrgID     string
 	peersByOrg      map[string][]fab.Peer
 }
 
+//ProviderOption customizes the client provider returned by NewFabricNetworkClientProvider
+type ProviderOption func(*clientProvider)
+
+//WithUser sets the signing identity used for the org user operations
+func WithUser(user mspapi.SigningIdentity) ProviderOption {
+	return func(cProv *clientProvider) {
+		cProv.user = user
+	}
+}
+
+//WithAdmin sets the signing identity used for the client org admin operations
+func WithAdmin(admin mspapi.SigningIdentity) ProviderOption {
+	return func(cProv *clientProvider) {
+		cProv.adminUser = admin
+		cProv.adminUsers = []mspapi.SigningIdentity{admin}
+		cProv.adminOverridden = true
+	}
+}
+
 //NewFabricNetworkClientProvider return an instance of the client Org's Fabric Network ClientProvider
-func NewFabricNetworkClientProvider(clientOrgID string, cfgOptions configs.ConfigOptions) FabricNetworkClientProvider {
+//The provider pins the current network config of the client org so that a config reload does not affect it until CloseSDK is called.
+func NewFabricNetworkClientProvider(clientOrgID string, cfgOptions configs.ConfigOptions, opts ...ProviderOption) (FabricNetworkClientProvider, error) {
+	pinned, release, err := cfgOptions.Pin(clientOrgID)
+	if err != nil {
+		return nil, err
+	}
 	clientProvider := new(clientProvider)
-	//clientProvider.cfgOptions = cfgOptions
-	clientProvider.sdk = cfgOptions.GetFabricSDK(clientOrgID)
-	clientProvider.peers = cfgOptions.GetClientOrgPeers(clientOrgID)
-	clientProvider.orgIDByPeer = cfgOptions.GetOrgsIDByPeers(clientOrgID)
-	clientProvider.orgsID = cfgOptions.GetOrgsID(clientOrgID)
-	clientProvider.userName = cfgOptions.GetUserName(clientOrgID)
-	clientProvider.user = cfgOptions.GetClientOrgUser(clientOrgID)
-	clientProvider.adminUser = cfgOptions.GetClientOrgAdminUser(clientOrgID)
-	clientProvider.orgMSPID = cfgOptions.GetClientOrgMSPID(clientOrgID)
-	clientProvider.peersByOrg = cfgOptions.GetAllPeersByOrg(clientOrgID)
-	return clientProvider
+	clientProvider.release = release
+	clientProvider.cfgOptions = pinned
+	clientProvider.cfgOrgID = clientOrgID
+	if err := clientProvider.init(); err != nil {
+		clientProvider.release()
+		return nil, err
+	}
+	clientProvider.sessions = make(map[string]context.ClientProvider)
+	clientProvider.channelSessions = make(map[string]context.ChannelProvider)
+	clientProvider.channelClients = make(map[string]*channel.Client)
+	clientProvider.ledgerClients = make(map[string]*ledger.Client)
+	for _, opt := range opts {
+		opt(clientProvider)
+	}
+	return clientProvider, nil
+}
+
+//init reads the network config of the client org, it fails if the client org is not configured
+func (cProv *clientProvider) init() error {
+	var err error
+	cfgOptions, clientOrgID := cProv.cfgOptions, cProv.cfgOrgID
+	if cProv.sdk, err = cfgOptions.GetFabricSDK(clientOrgID); err != nil {
+		return err
+	}
+	if cProv.clientOrgID, err = cfgOptions.GetClientOrgID(clientOrgID); err != nil {
+		return err
+	}
+	if cProv.peers, err = cfgOptions.GetClientOrgPeers(clientOrgID); err != nil {
+		return err
+	}
+	if cProv.orgIDByPeer, err = cfgOptions.GetOrgsIDByPeers(clientOrgID); err != nil {
+		return err
+	}
+	if cProv.orgsID, err = cfgOptions.GetOrgsID(clientOrgID); err != nil {
+		return err
+	}
+	if cProv.userName, err = cfgOptions.GetUserName(clientOrgID); err != nil {
+		return err
+	}
+	cProv.adminsByOrg = make(map[string][]string)
+	for _, orgID := range cProv.orgsID {
+		if cProv.adminsByOrg[orgID], err = cfgOptions.GetOrgAdminNames(clientOrgID, orgID); err != nil {
+			return err
+		}
+	}
+	if cProv.orgMSPID, err = cfgOptions.GetClientOrgMSPID(clientOrgID); err != nil {
+		return err
+	}
+	if cProv.peersByOrg, err = cfgOptions.GetAllPeersByOrg(clientOrgID); err != nil {
+		return err
+	}
+	return nil
 }
 
 //ResourceMgmtClient returns the resmgmt.Client for the org user
 func (cProv *clientProvider) ResourceMgmtClient() (*resmgmt.Client, error) {
+	user, err := cProv.clientUser()
+	if err != nil {
+		return nil, err
+	}
 	//Get resmgmt client
-	session, err := cProv.context(cProv.user)
+	session, err := cProv.context(user)
 	if err != nil {
 		return nil, errors.Errorf("Error occurred when attempting to retrieve context clientprovider: %s", err.Error())
 	}
@@ -87,8 +174,12 @@ func (cProv *clientProvider) ResourceMgmtClient() (*resmgmt.Client, error) {
 
 //ResourceMgmtClientByAdmin returns the resmgmt.Client for the org admin
 func (cProv *clientProvider) ResourceMgmtClientByAdmin() (*resmgmt.Client, error) {
+	admin := cProv.ClientAdminUser()
+	if admin == nil {
+		return nil, errors.Errorf("no admin identity of client org %s is loaded", cProv.clientOrgID)
+	}
 	//Get resmgmt client
-	session, err := cProv.context(cProv.adminUser)
+	session, err := cProv.context(admin)
 	if err != nil {
 		return nil, errors.Errorf("Error occurred when attempting to retrieve context clientprovider: %s", err.Error())
 	}
@@ -135,8 +226,31 @@ func (cProv *clientProvider) ResourceMgmtClientByOrg(username string, orgID stri
 	return resmgmtClient, nil
 }
 
+//ResourceMgmtClientByOrgAdmin returns the resmgmt.Client for the first configured admin of the specified org that resolves
+func (cProv *clientProvider) ResourceMgmtClientByOrgAdmin(orgID string) (*resmgmt.Client, error) {
+	if cProv.adminOverridden && orgID == cProv.clientOrgID {
+		return cProv.ResourceMgmtClientByAdmin()
+	}
+	admins := cProv.OrgAdmins(orgID)
+	if len(admins) == 0 {
+		return nil, errors.Errorf("no admin configured for org %s", orgID)
+	}
+	var lastErr error
+	for _, username := range admins {
+		resmgmtClient, err := cProv.ResourceMgmtClientByOrg(username, orgID)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return resmgmtClient, nil
+	}
+	return nil, errors.Errorf("No admin identity of org %s could be resolved. Error: %v", orgID, lastErr)
+}
+
 func (cProv *clientProvider) context(user mspapi.SigningIdentity) (context.ClientProvider, error) {
-	key := user.Identifier().MSPID + "_" + user.Identifier().ID
+	key := sessionKey(user)
+	cProv.sessionMutex.Lock()
+	defer cProv.sessionMutex.Unlock()
 	session := cProv.sessions[key]
 	if session == nil {
 		session = cProv.sdk.Context(fabsdk.WithIdentity(user))
@@ -145,8 +259,16 @@ func (cProv *clientProvider) context(user mspapi.SigningIdentity) (context.Clien
 	return session, nil
 }
 
+//sessionKey identifies the sessions of a user by its MSP, ID and enrollment certificate so that a renewed identity gets new sessions
+func sessionKey(user mspapi.SigningIdentity) string {
+	certHash := sha256.Sum256(user.EnrollmentCertificate())
+	return user.Identifier().MSPID + "_" + user.Identifier().ID + "_" + hex.EncodeToString(certHash[:])
+}
+
 func (cProv *clientProvider) channelContext(user mspapi.SigningIdentity, channelID string) (context.ChannelProvider, error) {
-	key := user.Identifier().MSPID + "_" + user.Identifier().ID + "_" + channelID
+	key := sessionKey(user) + "_" + channelID
+	cProv.sessionMutex.Lock()
+	defer cProv.sessionMutex.Unlock()
 	session := cProv.channelSessions[key]
 	if session == nil {
 		session = cProv.sdk.ChannelContext(channelID, fabsdk.WithIdentity(user))
@@ -167,12 +289,45 @@ func (cProv *clientProvider) ClientOrgMSPID() string {
 	return cProv.orgMSPID
 }
 
+//ClientAdminUser returns the client org admin. Unless overridden, it is resolved on each call so that renewed identities are used
 func (cProv *clientProvider) ClientAdminUser() mspapi.SigningIdentity {
-	return cProv.adminUser
+	if cProv.adminUser != nil {
+		return cProv.adminUser
+	}
+	//The client org of the pinned config is known to be configured, see init
+	adminUser, _ := cProv.cfgOptions.GetClientOrgAdminUser(cProv.cfgOrgID)
+	return adminUser
 }
 
+func (cProv *clientProvider) ClientAdminUsers() []mspapi.SigningIdentity {
+	if cProv.adminUsers != nil {
+		return cProv.adminUsers
+	}
+	adminUsers, _ := cProv.cfgOptions.GetClientOrgAdminUsers(cProv.cfgOrgID)
+	return adminUsers
+}
+
+func (cProv *clientProvider) OrgAdmins(orgID string) []string {
+	return cProv.adminsByOrg[orgID]
+}
+
+//ClientUser returns the client org user. Unless overridden, it is resolved on each call so that renewed identities are used
 func (cProv *clientProvider) ClientUser() mspapi.SigningIdentity {
-	return cProv.user
+	if cProv.user != nil {
+		return cProv.user
+	}
+	//The client org of the pinned config is known to be configured, see init
+	user, _ := cProv.cfgOptions.GetClientOrgUser(cProv.cfgOrgID)
+	return user
+}
+
+//clientUser returns the client org user, or an error if the client org has no user configured
+func (cProv *clientProvider) clientUser() (mspapi.SigningIdentity, error) {
+	user := cProv.ClientUser()
+	if user == nil {
+		return nil, errors.Errorf("client org %s has no user configured", cProv.clientOrgID)
+	}
+	return user, nil
 }
 
 func (cProv *clientProvider) ClientUserName() string {
@@ -187,27 +342,98 @@ func (cProv *clientProvider) PeersByOrgID() map[string][]fab.Peer {
 	return cProv.peersByOrg
 }
 
+//Stale reports whether the config was reloaded since the provider was created, in which case a new provider should be used
+func (cProv *clientProvider) Stale() bool {
+	return !cProv.cfgOptions.IsCurrent(cProv.cfgOrgID)
+}
+
+//CloseSDK releases the network config pinned by the provider. The SDK is closed by the config options once it is no longer current nor in use.
 func (cProv *clientProvider) CloseSDK() {
-	if cProv.sdk != nil {
-		fmt.Println("Closing SDK")
-		cProv.sdk.Close()
+	if cProv.release != nil {
+		cProv.release()
+	}
+}
+
+//ChannelContext returns the channel context provider of the org user, to use the SDK channel services directly
+func (cProv *clientProvider) ChannelContext(channelID string) (context.ChannelProvider, error) {
+	user, err := cProv.clientUser()
+	if err != nil {
+		return nil, err
+	}
+	return cProv.channelContext(user, channelID)
+}
+
+//ChannelContextWithIdentity returns the channel context provider of the identity, e.g. the identity of an external signer
+func (cProv *clientProvider) ChannelContextWithIdentity(channelID string, identity mspapi.SigningIdentity) (context.ChannelProvider, error) {
+	if identity == nil {
+		return nil, errors.New("identity is not set")
 	}
+	return cProv.channelContext(identity, channelID)
 }
 
-//ChannelClient returns the channel.Client for the org user
+//ChannelClient returns the channel.Client for the org user.
+//The channel client is cached per user and channel and is safe for concurrent use.
 func (cProv *clientProvider) ChannelClient(channelID string) (*channel.Client, error) {
+	user, err := cProv.clientUser()
+	if err != nil {
+		return nil, err
+	}
+	key := sessionKey(user) + "_" + channelID
+	cProv.sessionMutex.Lock()
+	channelClient := cProv.channelClients[key]
+	cProv.sessionMutex.Unlock()
+	if channelClient != nil {
+		return channelClient, nil
+	}
 	//Get resmgmt client
-	session, err := cProv.channelContext(cProv.user, channelID)
+	session, err := cProv.channelContext(user, channelID)
 	if err != nil {
 		return nil, errors.Errorf("Error occurred when attempting to retrieve context channel provider for channel: %s. Error - %s", channelID, err.Error())
 	}
 	channelClient, err1 := channel.New(session)
 	if err1 != nil {
-		return nil, errors.Errorf("Error occurred when attempting to retrieve channel client for channel: %s. Error - %s", channelID, err.Error())
+		return nil, errors.Errorf("Error occurred when attempting to retrieve channel client for channel: %s. Error - %s", channelID, err1.Error())
 	}
+	cProv.sessionMutex.Lock()
+	defer cProv.sessionMutex.Unlock()
+	if cached := cProv.channelClients[key]; cached != nil {
+		return cached, nil
+	}
+	cProv.channelClients[key] = channelClient
 	return channelClient, nil
 }
 
+//LedgerClient returns the ledger.Client for the org user.
+//The ledger client is cached per user and channel and is safe for concurrent use.
+func (cProv *clientProvider) LedgerClient(channelID string) (*ledger.Client, error) {
+	user, err := cProv.clientUser()
+	if err != nil {
+		return nil, err
+	}
+	key := sessionKey(user) + "_" + channelID
+	cProv.sessionMutex.Lock()
+	ledgerClient := cProv.ledgerClients[key]
+	cProv.sessionMutex.Unlock()
+	if ledgerClient != nil {
+		return ledgerClient, nil
+	}
+	session, err := cProv.channelContext(user, channelID)
+	if err != nil {
+		return nil, errors.Errorf("Error occurred when attempting to retrieve context channel provider for channel: %s. Error - %s", channelID, err.Error())
+	}
+	ledgerClient, err = ledger.New(session)
+	if err != nil {
+		return nil, errors.Errorf("Error occurred when attempting to retrieve ledger client for channel: %s. Error - %s", channelID, err.Error())
+	}
+	cProv.sessionMutex.Lock()
+	defer cProv.sessionMutex.Unlock()
+	if cached := cProv.ledgerClients[key]; cached != nil {
+		return cached, nil
+	}
+	cProv.ledgerClients[key] = ledgerClient
+	return ledgerClient, nil
+}
+
 func (cProv *clientProvider) mspUser(username string) (mspapi.SigningIdentity, error) {
 	mspClient, err := msp.New(cProv.sdk.Context(), msp.WithOrg(cProv.clientOrgID))
 	if err != nil {